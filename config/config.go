@@ -0,0 +1,550 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"yuanbao/models"
+
+	"github.com/robfig/cron/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix 环境变量前缀，如 YUANBAO_SERVER_ADDR 覆盖 server.addr
+const EnvPrefix = "YUANBAO"
+
+// Config 应用配置
+type Config struct {
+	Server     ServerConfig     `yaml:"server"`
+	Identity   IdentityConfig   `yaml:"identity"`
+	Database   DatabaseConfig   `yaml:"database"`
+	RateLimit  RateLimitConfig  `yaml:"rate_limit"`
+	Command    CommandConfig    `yaml:"command"`
+	Selection  SelectionConfig  `yaml:"selection"`
+	Claim      ClaimConfig      `yaml:"claim"`
+	Feedback   FeedbackConfig   `yaml:"feedback"`
+	Moderation ModerationConfig `yaml:"moderation"`
+	Admin      AdminConfig      `yaml:"admin"`
+	Crawler    CrawlerConfig    `yaml:"crawler"`
+	Scheduler  SchedulerConfig  `yaml:"scheduler"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+}
+
+// ServerConfig HTTP 服务配置
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`             // 监听地址
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"` // 收到退出信号后等待请求和后台任务结束的最长时间
+	InstanceID      string        `yaml:"instance_id"`      // 实例标识，共享数据库的多个实例各不相同且重启后保持不变，默认为主机名
+}
+
+// IdentityConfig 客户端标识配置（限流、不展示自己上传的口令、举报去重等都按该标识区分）
+type IdentityConfig struct {
+	TrustedProxies []string      `yaml:"trusted_proxies"` // 可信反向代理的 IP 或 CIDR，只有来自这些地址的 X-Forwarded-For 才会被采信
+	IPv6Prefix     int           `yaml:"ipv6_prefix"`     // IPv6 地址按该前缀长度聚合为一个客户端
+	HashKeys       []string      `yaml:"hash_keys"`       // HMAC 密钥，第一个用于新数据，其余用于匹配轮换前的数据
+	Retention      time.Duration `yaml:"retention"`       // 超过该时间后清空记录中的客户端标识
+}
+
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Driver string `yaml:"driver"` // sqlite / postgres / mysql
+	DSN    string `yaml:"dsn"`    // 连接串，SQLite 为文件路径
+}
+
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	Store  string    `yaml:"store"`  // 限流状态存储：memory / database
+	Upload LimitRule `yaml:"upload"` // 上传口令
+	Get    LimitRule `yaml:"get"`    // 获取口令
+}
+
+// 支持的限流状态存储
+const (
+	RateLimitStoreMemory   = "memory"   // 进程内存，多实例各自计数，重启清零
+	RateLimitStoreDatabase = "database" // 数据库，多实例共享配额，重启后保留
+)
+
+// 支持的限流算法
+const (
+	AlgorithmFixedWindow = "fixed_window" // 固定窗口：窗口结束时计数清零
+	AlgorithmSlidingLog  = "sliding_log"  // 滑动日志：统计最近 window 内的请求
+	AlgorithmTokenBucket = "token_bucket" // 令牌桶：按 limit/window 匀速补充，最多积累 burst 个
+)
+
+// LimitRule 单个限流规则：window 时间内最多 limit 次
+type LimitRule struct {
+	Limit     int           `yaml:"limit"`
+	Window    time.Duration `yaml:"window"`
+	Algorithm string        `yaml:"algorithm"` // fixed_window / sliding_log / token_bucket
+	Burst     int           `yaml:"burst"`     // 令牌桶容量，0 表示等于 limit（仅 token_bucket）
+}
+
+// CommandConfig 口令规则配置
+type CommandConfig struct {
+	MaxDisplayCount int           `yaml:"max_display_count"` // 每个口令最多展示次数
+	MinLength       int           `yaml:"min_length"`        // 原文最小长度（字节）
+	MinCodeLength   int           `yaml:"min_code_length"`   // 解析出的口令本体最小长度（字节）
+	MaxLength       int           `yaml:"max_length"`        // 最大长度（字节）
+	DefaultTTL      time.Duration `yaml:"default_ttl"`       // 上传时未指定过期时间的口令有效期，0 表示不过期
+	MaxTTL          time.Duration `yaml:"max_ttl"`           // 上传时指定的过期时间最晚为当前时间加上该时长
+	SweepInterval   time.Duration `yaml:"sweep_interval"`    // 将到期口令标记为过期的执行间隔
+	Retention       time.Duration `yaml:"retention"`         // 过期、下架超过该时长的口令连同领取等记录一起删除，0 表示不删除
+}
+
+// 内置口令选择策略
+const (
+	StrategyUserFirst      = "user_first"      // 优先用户上传，同来源内按反馈成功率，再随机
+	StrategyFreshest       = "freshest"        // 最新入库的优先
+	StrategyLeastDisplayed = "least_displayed" // 展示次数最少的优先
+	StrategySuccessRate    = "success_rate"    // 不区分来源，按反馈成功率
+)
+
+// SelectionConfig 口令选择策略配置
+// 每次获取口令时按权重随机选择一个策略，可用于 A/B 测试，所用策略记录在发放记录上
+type SelectionConfig struct {
+	Strategies map[string]int `yaml:"strategies"`  // 策略名 -> 权重，权重为0表示不使用；未配置时只使用 user_first
+	SampleSize int            `yaml:"sample_size"` // 每次从随机位置抽取的候选数（默认50），策略排序只在候选内生效；0 表示对全部可用口令排序
+}
+
+// ClaimConfig 口令领取（租约）配置
+type ClaimConfig struct {
+	TTL          time.Duration `yaml:"ttl"`           // 领取后等待确认的时长，超时自动归还
+	ReapInterval time.Duration `yaml:"reap_interval"` // 回收超时租约的执行间隔
+}
+
+// FeedbackConfig 领取结果反馈配置
+type FeedbackConfig struct {
+	FailureOutcomes     []string `yaml:"failure_outcomes"`      // 计为失败的反馈结果
+	RetireAfterFailures int      `yaml:"retire_after_failures"` // 失败反馈达到该次数后下架口令
+}
+
+// ModerationConfig 无效举报配置
+type ModerationConfig struct {
+	ReportThreshold int `yaml:"report_threshold"` // 不同IP举报达到该数量后隔离口令（上传者本人的举报不计入）
+}
+
+// AdminConfig 管理接口配置，令牌和账号密码至少配置一种，否则不开放管理接口
+type AdminConfig struct {
+	Token    string `yaml:"token"`    // 管理令牌（Authorization: Bearer <token>）
+	Username string `yaml:"username"` // Basic Auth 用户名
+	Password string `yaml:"password"` // Basic Auth 密码
+}
+
+// Enabled 是否开放管理接口
+func (c AdminConfig) Enabled() bool {
+	return c.Token != "" || (c.Username != "" && c.Password != "")
+}
+
+// 内置采集来源名称
+const (
+	SourceTiebaThread   = "tieba_thread"   // 方案1：单个帖子
+	SourceTiebaHomepage = "tieba_homepage" // 方案2：元宝吧首页
+	SourceDirectory     = "directory"      // 本地目录导入
+)
+
+// CrawlerConfig 爬虫及清理任务配置
+type CrawlerConfig struct {
+	Enabled          bool            `yaml:"enabled"`           // 是否启动爬虫定时任务
+	StartupDelay     time.Duration   `yaml:"startup_delay"`     // 启动后首次执行前的等待时间
+	ThreadInterval   time.Duration   `yaml:"thread_interval"`   // 方案1（单个帖子）执行间隔
+	HomepageInterval time.Duration   `yaml:"homepage_interval"` // 方案2（元宝吧首页）执行间隔
+	CommandTTL       time.Duration   `yaml:"command_ttl"`       // 爬虫口令有效期，从发布时间（未知时为采集时间）算起
+	Cookies          []string        `yaml:"cookies"`           // 贴吧Cookie列表，每次请求随机选择一个
+	ThreadURL        string          `yaml:"thread_url"`        // 方案1爬取的帖子地址
+	HomepageURL      string          `yaml:"homepage_url"`      // 方案2爬取的贴吧首页地址
+	TimeThreshold    time.Duration   `yaml:"time_threshold"`    // 只采集该时间内发布的楼层
+	MaxThreads       int             `yaml:"max_threads"`       // 方案2最多爬取的帖子数
+	RequestTimeout   time.Duration   `yaml:"request_timeout"`   // 单次HTTP请求超时
+	ExtraThreads     []SourceConfig  `yaml:"extra_threads"`     // 额外爬取的贴吧帖子（需要Cookie）
+	Directory        DirectoryConfig `yaml:"directory"`         // 本地目录导入
+	Feeds            []SourceConfig  `yaml:"feeds"`             // RSS/Atom 订阅
+}
+
+// SourceConfig 额外采集来源配置（仅支持配置文件，不支持环境变量覆盖）
+type SourceConfig struct {
+	Name     string        `yaml:"name"`     // 来源名称，记录在口令的 origin 字段，不能重复
+	URL      string        `yaml:"url"`      // 帖子或订阅地址
+	Interval time.Duration `yaml:"interval"` // 执行间隔
+	MaxAge   time.Duration `yaml:"max_age"`  // 订阅只导入该时间内发布的条目，默认等于 interval
+}
+
+// DirectoryConfig 本地目录导入配置：目录中的 .txt 文件每行一个口令，导入后重命名为 .txt.done
+type DirectoryConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Path     string        `yaml:"path"`     // 投放目录
+	Interval time.Duration `yaml:"interval"` // 扫描间隔
+}
+
+// SchedulerConfig 定时任务配置
+type SchedulerConfig struct {
+	Jitter time.Duration        `yaml:"jitter"` // 定时触发的默认随机延迟上限，0 表示不延迟
+	Jobs   map[string]JobConfig `yaml:"jobs"`   // 按任务名覆盖默认设置（仅支持配置文件）
+}
+
+// JobConfig 单个定时任务的覆盖设置，未填写的字段使用默认值
+type JobConfig struct {
+	Spec    string         `yaml:"spec"`    // cron 表达式，如 "*/30 * * * *"、"@every 1h"
+	Jitter  *time.Duration `yaml:"jitter"`  // 随机延迟上限
+	Enabled *bool          `yaml:"enabled"` // 是否按计划执行
+}
+
+// MetricsConfig Prometheus 监控配置
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"` // 是否暴露监控指标
+	Path    string `yaml:"path"`    // 指标抓取路径
+}
+
+// Default 返回默认配置
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:            ":18080",
+			ShutdownTimeout: 15 * time.Second,
+			InstanceID:      defaultInstanceID(),
+		},
+		Identity: IdentityConfig{
+			TrustedProxies: []string{"127.0.0.1", "::1"},
+			IPv6Prefix:     64,
+			Retention:      7 * 24 * time.Hour,
+		},
+		Database: DatabaseConfig{
+			Driver: DriverSQLite,
+			DSN:    "yuanbao.db",
+		},
+		RateLimit: RateLimitConfig{
+			Store:  RateLimitStoreMemory,
+			Upload: LimitRule{Limit: 5, Window: time.Minute, Algorithm: AlgorithmSlidingLog},
+			Get:    LimitRule{Limit: 20, Window: time.Minute, Algorithm: AlgorithmTokenBucket},
+		},
+		Command: CommandConfig{
+			MaxDisplayCount: 3,
+			MinLength:       10,
+			MinCodeLength:   4,
+			MaxLength:       500,
+			DefaultTTL:      24 * time.Hour,
+			MaxTTL:          7 * 24 * time.Hour,
+			SweepInterval:   time.Minute,
+			Retention:       30 * 24 * time.Hour,
+		},
+		Selection: SelectionConfig{
+			SampleSize: 50,
+		},
+		Claim: ClaimConfig{
+			TTL:          10 * time.Minute,
+			ReapInterval: time.Minute,
+		},
+		Feedback: FeedbackConfig{
+			FailureOutcomes:     []string{models.OutcomeAlreadyClaimed, models.OutcomeExpired, models.OutcomeMalformed},
+			RetireAfterFailures: 2,
+		},
+		Moderation: ModerationConfig{
+			ReportThreshold: 3,
+		},
+		Crawler: CrawlerConfig{
+			Enabled:          true,
+			StartupDelay:     5 * time.Second,
+			ThreadInterval:   30 * time.Minute,
+			HomepageInterval: time.Hour,
+			CommandTTL:       time.Hour,
+			ThreadURL:        "https://tieba.baidu.com/p/10449473531",
+			HomepageURL:      "https://tieba.baidu.com/f?ie=utf-8&kw=%E8%85%BE%E8%AE%AF%E5%85%83%E5%AE%9D&fr=search",
+			TimeThreshold:    20 * time.Minute,
+			MaxThreads:       10,
+			RequestTimeout:   10 * time.Second,
+			Directory: DirectoryConfig{
+				Path:     "harvest",
+				Interval: 5 * time.Minute,
+			},
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+	}
+}
+
+// Load 加载配置：默认值 -> 配置文件 -> YUANBAO_* 环境变量，最后校验
+// path 为空或文件不存在且 required 为 false 时仅使用默认值和环境变量
+func Load(path string, required bool) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, cfg); err != nil {
+				return nil, fmt.Errorf("解析配置文件 %s 失败: %v", path, err)
+			}
+		case errors.Is(err, os.ErrNotExist) && !required:
+			// 使用默认配置
+		default:
+			return nil, fmt.Errorf("读取配置文件 %s 失败: %v", path, err)
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), EnvPrefix); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// defaultInstanceID 默认实例标识：主机名，获取失败时为 default
+func defaultInstanceID() string {
+	if name, err := os.Hostname(); err == nil && name != "" {
+		return name
+	}
+	return "default"
+}
+
+// Validate 校验配置
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr 不能为空")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout 必须大于0")
+	check(c.Server.InstanceID != "", "server.instance_id 不能为空")
+
+	for _, proxy := range c.Identity.TrustedProxies {
+		check(validIPOrCIDR(proxy), "identity.trusted_proxies 格式错误: %q", proxy)
+	}
+	check(c.Identity.IPv6Prefix > 0 && c.Identity.IPv6Prefix <= 128, "identity.ipv6_prefix 必须在1到128之间")
+	for i, key := range c.Identity.HashKeys {
+		check(len(key) >= 16, "identity.hash_keys[%d] 长度不能少于16个字符", i)
+	}
+	check(c.Identity.Retention > 0, "identity.retention 必须大于0")
+	// 未配置密钥时每个进程使用各自的随机密钥，共享数据库或限流状态的多个实例会把同一客户端散列成不同的标识
+	shared := c.Database.Driver != DriverSQLite || c.RateLimit.Store == RateLimitStoreDatabase
+	check(len(c.Identity.HashKeys) > 0 || !shared,
+		"使用 %s 数据库或 rate_limit.store: database 时必须配置 identity.hash_keys，否则各实例的客户端标识不一致", c.Database.Driver)
+
+	switch c.Database.Driver {
+	case DriverSQLite, DriverPostgres, DriverMySQL:
+	default:
+		problems = append(problems, fmt.Sprintf("database.driver 不支持: %q", c.Database.Driver))
+	}
+	check(c.Database.DSN != "", "database.dsn 不能为空")
+
+	switch c.RateLimit.Store {
+	case RateLimitStoreMemory, RateLimitStoreDatabase:
+	default:
+		problems = append(problems, fmt.Sprintf("rate_limit.store 不支持: %q", c.RateLimit.Store))
+	}
+	for name, rule := range map[string]LimitRule{"upload": c.RateLimit.Upload, "get": c.RateLimit.Get} {
+		check(rule.Limit > 0, "rate_limit.%s.limit 必须大于0", name)
+		check(rule.Window > 0, "rate_limit.%s.window 必须大于0", name)
+		switch rule.Algorithm {
+		case AlgorithmFixedWindow, AlgorithmSlidingLog:
+			check(rule.Burst == 0, "rate_limit.%s.burst 仅适用于 token_bucket", name)
+		case AlgorithmTokenBucket:
+			check(rule.Burst >= 0, "rate_limit.%s.burst 不能为负数", name)
+		default:
+			problems = append(problems, fmt.Sprintf("rate_limit.%s.algorithm 不支持: %q", name, rule.Algorithm))
+		}
+	}
+
+	check(c.Command.MaxDisplayCount > 0, "command.max_display_count 必须大于0")
+	check(c.Command.MinLength > 0, "command.min_length 必须大于0")
+	check(c.Command.MinCodeLength > 0, "command.min_code_length 必须大于0")
+	check(c.Command.MaxLength >= c.Command.MinLength, "command.max_length 不能小于 min_length")
+	check(c.Command.DefaultTTL >= 0, "command.default_ttl 不能为负数")
+	check(c.Command.MaxTTL > 0, "command.max_ttl 必须大于0")
+	check(c.Command.DefaultTTL <= c.Command.MaxTTL, "command.default_ttl 不能大于 max_ttl")
+	check(c.Command.SweepInterval > 0, "command.sweep_interval 必须大于0")
+	check(c.Command.Retention >= 0, "command.retention 不能为负数")
+	check(c.Crawler.CommandTTL > 0, "crawler.command_ttl 必须大于0")
+
+	strategyNames := make([]string, 0, len(c.Selection.Strategies))
+	for name := range c.Selection.Strategies {
+		strategyNames = append(strategyNames, name)
+	}
+	sort.Strings(strategyNames)
+	totalWeight := 0
+	for _, name := range strategyNames {
+		weight := c.Selection.Strategies[name]
+		switch name {
+		case StrategyUserFirst, StrategyFreshest, StrategyLeastDisplayed, StrategySuccessRate:
+		default:
+			problems = append(problems, fmt.Sprintf("selection.strategies 中的策略不存在: %q", name))
+		}
+		check(weight >= 0, "selection.strategies.%s 权重不能为负数", name)
+		totalWeight += weight
+	}
+	check(len(strategyNames) == 0 || totalWeight > 0, "selection.strategies 至少需要一个权重大于0的策略")
+	check(c.Selection.SampleSize >= 0, "selection.sample_size 不能为负数")
+
+	check(c.Claim.TTL > 0, "claim.ttl 必须大于0")
+	check(c.Claim.ReapInterval > 0, "claim.reap_interval 必须大于0")
+
+	check(c.Feedback.RetireAfterFailures > 0, "feedback.retire_after_failures 必须大于0")
+	for _, outcome := range c.Feedback.FailureOutcomes {
+		check(models.IsValidOutcome(outcome) && outcome != models.OutcomeSuccess, "feedback.failure_outcomes 不支持: %q", outcome)
+	}
+
+	check(c.Moderation.ReportThreshold > 0, "moderation.report_threshold 必须大于0")
+	check((c.Admin.Username == "") == (c.Admin.Password == ""), "admin.username 和 admin.password 必须同时配置")
+
+	if c.Crawler.Enabled {
+		check(c.Crawler.StartupDelay >= 0, "crawler.startup_delay 不能为负数")
+		check(c.Crawler.ThreadInterval > 0, "crawler.thread_interval 必须大于0")
+		check(c.Crawler.HomepageInterval > 0, "crawler.homepage_interval 必须大于0")
+		check(c.Crawler.ThreadURL != "", "crawler.thread_url 不能为空")
+		check(c.Crawler.HomepageURL != "", "crawler.homepage_url 不能为空")
+		check(c.Crawler.TimeThreshold > 0, "crawler.time_threshold 必须大于0")
+		check(c.Crawler.MaxThreads > 0, "crawler.max_threads 必须大于0")
+		check(c.Crawler.RequestTimeout > 0, "crawler.request_timeout 必须大于0")
+
+		if c.Crawler.Directory.Enabled {
+			check(c.Crawler.Directory.Path != "", "crawler.directory.path 不能为空")
+			check(c.Crawler.Directory.Interval > 0, "crawler.directory.interval 必须大于0")
+		}
+
+		// 来源名称不能与内置来源重复
+		names := map[string]bool{SourceTiebaThread: true, SourceTiebaHomepage: true, SourceDirectory: true}
+		groups := []struct {
+			field string
+			list  []SourceConfig
+		}{{"extra_threads", c.Crawler.ExtraThreads}, {"feeds", c.Crawler.Feeds}}
+		for _, group := range groups {
+			field := group.field
+			for i, src := range group.list {
+				check(src.Name != "" && !names[src.Name], "crawler.%s[%d].name 为空或重复: %q", field, i, src.Name)
+				check(src.URL != "", "crawler.%s[%d].url 不能为空", field, i)
+				check(src.Interval > 0, "crawler.%s[%d].interval 必须大于0", field, i)
+				check(src.MaxAge >= 0, "crawler.%s[%d].max_age 不能为负数", field, i)
+				names[src.Name] = true
+			}
+		}
+	}
+
+	check(c.Scheduler.Jitter >= 0, "scheduler.jitter 不能为负数")
+	jobNames := make([]string, 0, len(c.Scheduler.Jobs))
+	for name := range c.Scheduler.Jobs {
+		jobNames = append(jobNames, name)
+	}
+	sort.Strings(jobNames)
+	for _, name := range jobNames {
+		job := c.Scheduler.Jobs[name]
+		if job.Spec != "" {
+			_, err := cron.ParseStandard(job.Spec)
+			check(err == nil, "scheduler.jobs.%s.spec 无效: %v", name, err)
+		}
+		check(job.Jitter == nil || *job.Jitter >= 0, "scheduler.jobs.%s.jitter 不能为负数", name)
+	}
+
+	if c.Metrics.Enabled {
+		check(strings.HasPrefix(c.Metrics.Path, "/"), "metrics.path 必须以 / 开头")
+		check(!strings.HasPrefix(c.Metrics.Path, "/api/") && !strings.HasPrefix(c.Metrics.Path, "/static/"),
+			"metrics.path 不能与 /api、/static 路由冲突")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("配置校验失败:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// validIPOrCIDR 判断是否为合法的 IP 地址或 CIDR
+func validIPOrCIDR(s string) bool {
+	if strings.Contains(s, "/") {
+		_, _, err := net.ParseCIDR(s)
+		return err == nil
+	}
+	return net.ParseIP(s) != nil
+}
+
+// applyEnv 按 yaml 标签递归应用环境变量覆盖
+// 例如 rate_limit.upload.limit 对应 YUANBAO_RATE_LIMIT_UPLOAD_LIMIT
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		name := prefix + "_" + strings.ToUpper(tag)
+		fv := v.Field(i)
+
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			if err := applyEnv(fv, name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(fv, raw); err != nil {
+			return fmt.Errorf("环境变量 %s 格式错误: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// setField 将字符串值写入字段
+func setField(fv reflect.Value, raw string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的类型 %s", fv.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		fv.Set(reflect.ValueOf(items).Convert(fv.Type()))
+	default:
+		return fmt.Errorf("不支持的类型 %s", fv.Type())
+	}
+	return nil
+}
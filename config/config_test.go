@@ -0,0 +1,27 @@
+package config
+
+import "testing"
+
+func TestValidateRequiresHashKeysWhenShared(t *testing.T) {
+	cases := []struct {
+		name     string
+		driver   string
+		store    string
+		hashKeys []string
+		wantErr  bool
+	}{
+		{"sqlite with memory limiter", DriverSQLite, RateLimitStoreMemory, nil, false},
+		{"sqlite with database limiter", DriverSQLite, RateLimitStoreDatabase, nil, true},
+		{"postgres", DriverPostgres, RateLimitStoreMemory, nil, true},
+		{"mysql with key", DriverMySQL, RateLimitStoreDatabase, []string{"0123456789abcdef"}, false},
+	}
+	for _, c := range cases {
+		cfg := Default()
+		cfg.Database.Driver = c.driver
+		cfg.RateLimit.Store = c.store
+		cfg.Identity.HashKeys = c.hashKeys
+		if err := cfg.Validate(); (err != nil) != c.wantErr {
+			t.Errorf("%s: Validate() = %v, want error %v", c.name, err, c.wantErr)
+		}
+	}
+}
@@ -3,7 +3,10 @@ package config
 import (
 	"fmt"
 	"log"
+	"strings"
 
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -11,10 +14,22 @@ import (
 
 var DB *gorm.DB
 
+// 支持的数据库驱动
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
 // InitDB 初始化数据库连接
-func InitDB() {
-	var err error
-	DB, err = gorm.Open(sqlite.Open("yuanbao.db"), &gorm.Config{
+// driver 为 sqlite / postgres / mysql，dsn 为对应驱动的连接串（SQLite 为文件路径）
+func InitDB(driver, dsn string) {
+	dialector, err := openDialector(driver, dsn)
+	if err != nil {
+		log.Fatal("数据库配置错误:", err)
+	}
+
+	DB, err = gorm.Open(dialector, &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Info),
 	})
 
@@ -22,5 +37,51 @@ func InitDB() {
 		log.Fatal("数据库连接失败:", err)
 	}
 
-	fmt.Println("数据库连接成功！")
+	fmt.Printf("数据库连接成功！（%s）\n", driver)
+}
+
+// CloseDB 关闭数据库连接
+func CloseDB() error {
+	if DB == nil {
+		return nil
+	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// sqliteDSN 为 SQLite 连接串补充并发参数（已指定的参数不覆盖）
+// _txlock=immediate：事务开始即取得写锁（BEGIN IMMEDIATE），避免两个事务先读后写时互相等待导致 SQLITE_BUSY
+// _busy_timeout：写锁被占用时最多等待的毫秒数，而不是立即报错
+func sqliteDSN(dsn string) string {
+	params := []string{"_txlock=immediate", "_busy_timeout=5000"}
+	for _, param := range params {
+		name := param[:strings.Index(param, "=")+1]
+		if strings.Contains(dsn, name) {
+			continue
+		}
+		if strings.Contains(dsn, "?") {
+			dsn += "&" + param
+		} else {
+			dsn += "?" + param
+		}
+	}
+	return dsn
+}
+
+// openDialector 根据驱动名创建 GORM Dialector
+func openDialector(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case DriverSQLite:
+		return sqlite.Open(sqliteDSN(dsn)), nil
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	case DriverMySQL:
+		return mysql.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
 }
@@ -0,0 +1,321 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"yuanbao/identity"
+	"yuanbao/models"
+	"yuanbao/repositories"
+	"yuanbao/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 分页参数
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// getPagination 解析分页参数 page / page_size
+func getPagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultPageSize)))
+	if err != nil || pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return page, pageSize
+}
+
+// getIDParam 解析路径中的口令ID
+func getIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		respondError(c, services.ErrInvalidInput)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// ListQuarantined 查询隔离区口令
+func ListQuarantined(c *gin.Context) {
+	page, pageSize := getPagination(c)
+
+	commands, total, err := services.ListQuarantined(page, pageSize)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"code":     codeOK,
+		"items":    commands,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// RestoreQuarantined 恢复被隔离的口令
+func RestoreQuarantined(c *gin.Context) {
+	id, ok := getIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := services.RestoreQuarantined(id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": "已恢复",
+	})
+}
+
+// PurgeQuarantined 彻底删除被隔离的口令
+func PurgeQuarantined(c *gin.Context) {
+	id, ok := getIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := services.PurgeQuarantined(id); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": "已删除",
+	})
+}
+
+// parseCommandFilter 解析口令查询条件
+// 支持 source、status、uploader_ip（原始 IP 或散列后的标识）、q（内容关键字）、min_display、max_display、min_age、max_age（如 30m、24h）
+func parseCommandFilter(c *gin.Context) (repositories.CommandFilter, error) {
+	filter := repositories.CommandFilter{
+		Source:  c.Query("source"),
+		Status:  c.Query("status"),
+		Keyword: c.Query("q"),
+	}
+	if filter.Status != "" && !models.IsValidCommandStatus(filter.Status) {
+		return filter, fmt.Errorf("未知的口令状态: %s", filter.Status)
+	}
+	if ip := c.Query("uploader_ip"); ip != "" {
+		filter.UploaderIPs = identity.Lookup(ip)
+	}
+
+	for param, target := range map[string]**int{
+		"min_display": &filter.MinDisplayCount,
+		"max_display": &filter.MaxDisplayCount,
+	} {
+		if raw := c.Query(param); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				return filter, err
+			}
+			*target = &n
+		}
+	}
+
+	now := time.Now()
+	if raw := c.Query("min_age"); raw != "" {
+		age, err := time.ParseDuration(raw)
+		if err != nil {
+			return filter, err
+		}
+		before := now.Add(-age)
+		filter.CreatedBefore = &before
+	}
+	if raw := c.Query("max_age"); raw != "" {
+		age, err := time.ParseDuration(raw)
+		if err != nil {
+			return filter, err
+		}
+		after := now.Add(-age)
+		filter.CreatedAfter = &after
+	}
+
+	return filter, nil
+}
+
+// ListCommands 查询口令列表
+func ListCommands(c *gin.Context) {
+	filter, err := parseCommandFilter(c)
+	if err != nil {
+		respondError(c, services.ErrInvalidInput)
+		return
+	}
+	page, pageSize := getPagination(c)
+
+	commands, total, err := services.ListCommands(filter, page, pageSize)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"code":     codeOK,
+		"items":    commands,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// AddCommandRequest 手动添加口令请求
+type AddCommandRequest struct {
+	Content      string     `json:"content" binding:"required"`
+	Source       string     `json:"source"`        // 默认为 admin
+	DisplayLimit int        `json:"display_limit"` // 默认使用 command.max_display_count
+	ExpiresAt    *time.Time `json:"expires_at"`    // 默认使用 command.default_ttl
+}
+
+// AddCommand 手动添加口令
+func AddCommand(c *gin.Context) {
+	var req AddCommandRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, services.ErrEmptyContent)
+		return
+	}
+
+	command, err := services.AddCommand(req.Content, req.Source, req.DisplayLimit, req.ExpiresAt)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": "口令添加成功",
+		"item":    command,
+	})
+}
+
+// UpdateCommandRequest 修改口令展示次数请求，未传的字段不修改
+type UpdateCommandRequest struct {
+	DisplayLimit *int `json:"display_limit"`
+	DisplayCount *int `json:"display_count"`
+}
+
+// UpdateCommand 修改口令展示上限/已展示次数
+func UpdateCommand(c *gin.Context) {
+	id, ok := getIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, services.ErrInvalidInput)
+		return
+	}
+
+	if err := services.UpdateCommandDisplay(id, req.DisplayLimit, req.DisplayCount); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": "已更新",
+	})
+}
+
+// ListCommandTransitions 查询口令的状态变化记录
+func ListCommandTransitions(c *gin.Context) {
+	id, ok := getIDParam(c)
+	if !ok {
+		return
+	}
+
+	transitions, err := services.ListTransitions(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"items":   transitions,
+	})
+}
+
+// BulkDeleteRequest 批量删除请求
+type BulkDeleteRequest struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
+
+// BulkDeleteCommands 批量删除口令
+func BulkDeleteCommands(c *gin.Context) {
+	var req BulkDeleteRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, services.ErrInvalidInput)
+		return
+	}
+
+	deleted, err := services.DeleteCommands(req.IDs)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": "删除成功",
+		"deleted": deleted,
+	})
+}
+
+// TriggerCrawler 手动触发爬虫
+func TriggerCrawler(c *gin.Context) {
+	if err := services.TriggerCrawler(c.Param("name")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": "爬虫已在后台启动",
+	})
+}
+
+// ListCrawlerSources 列出已注册的采集来源
+func ListCrawlerSources(c *gin.Context) {
+	items := make([]gin.H, 0)
+	for _, src := range services.CrawlerSources() {
+		items = append(items, gin.H{
+			"name":     src.Name(),
+			"schedule": src.Schedule().String(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"items":   items,
+	})
+}
@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"net/http"
+	"yuanbao/identity"
+	"yuanbao/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConfirmClaim 确认领取口令（计入展示次数）
+func ConfirmClaim(c *gin.Context) {
+	err := services.ConfirmClaim(c.Param("token"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": "已确认领取",
+	})
+}
+
+// ReleaseClaim 归还口令（未使用，名额回到口令池）
+func ReleaseClaim(c *gin.Context) {
+	err := services.ReleaseClaim(c.Param("token"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": "已归还口令",
+	})
+}
+
+// FeedbackRequest 领取结果反馈请求
+type FeedbackRequest struct {
+	Outcome string `json:"outcome" binding:"required"` // success / already_claimed / expired / malformed
+}
+
+// SubmitFeedback 反馈口令领取结果
+func SubmitFeedback(c *gin.Context) {
+	var req FeedbackRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, services.ErrInvalidInput)
+		return
+	}
+
+	err := services.SubmitFeedback(c.Param("token"), req.Outcome, identity.FromContext(c))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": "感谢反馈",
+	})
+}
@@ -2,24 +2,17 @@ package controllers
 
 import (
 	"net/http"
+	"time"
+	"yuanbao/identity"
 	"yuanbao/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-// getClientIP 获取并标准化客户端IP
-func getClientIP(c *gin.Context) string {
-	ip := c.ClientIP()
-	// 将IPv6本地地址转换为IPv4格式
-	if ip == "::1" {
-		return "127.0.0.1"
-	}
-	return ip
-}
-
 // UploadCommandRequest 上传口令请求
 type UploadCommandRequest struct {
-	Content string `json:"content" binding:"required"`
+	Content   string     `json:"content" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"` // 红包失效时间（RFC 3339），不传时使用默认有效期
 }
 
 // UploadCommand 上传口令
@@ -27,27 +20,22 @@ func UploadCommand(c *gin.Context) {
 	var req UploadCommandRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "口令内容不能为空",
-		})
+		respondError(c, services.ErrEmptyContent)
 		return
 	}
 
 	// 获取客户端IP（标准化处理）
-	clientIP := getClientIP(c)
+	clientIP := identity.FromContext(c)
 
-	command, err := services.SaveCommand(req.Content, clientIP)
+	command, err := services.SaveCommand(req.Content, clientIP, req.ExpiresAt)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": err.Error(), // 返回具体的错误信息
-		})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
+		"code":    codeOK,
 		"message": "口令上传成功",
 		"id":      command.ID,
 	})
@@ -55,29 +43,26 @@ func UploadCommand(c *gin.Context) {
 
 // GetRandomCommand 随机获取口令
 func GetRandomCommand(c *gin.Context) {
-	// 获取客户端IP（标准化处理）
-	clientIP := getClientIP(c)
+	// 获取客户端标识（包括密钥轮换前的标识，用于排除自己上传的口令）
+	clientIDs := identity.AliasesFromContext(c)
 
-	command, err := services.GetRandomCommand(clientIP)
+	command, claim, err := services.GetRandomCommand(clientIDs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "获取失败",
-		})
-		return
-	}
-
-	if command == nil {
-		c.JSON(http.StatusOK, gin.H{
-			"success": false,
-			"message": "暂无可用口令",
-		})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"success":   true,
-		"content":   command.Content,
-		"createdAt": command.CreatedAt,
+		"success":     true,
+		"code":        codeOK,
+		"content":     command.Payload(),   // 解析出的口令本体
+		"amountCents": command.AmountCents, // 文案中的红包金额（分），0 表示未提及
+		"campaign":    command.Campaign,    // 文案中的活动名称
+		"createdAt":   command.CreatedAt,
+		"validUntil":  command.ExpiresAt,     // 口令失效时间，null 表示不过期
+		"token":       claim.Token,           // 领取令牌，用于确认、归还或反馈
+		"expiresAt":   claim.ExpiresAt,       // 超过该时间未确认将自动归还
+		"successRate": command.SuccessRate(), // 历史反馈成功率
 	})
 }
 
@@ -85,14 +70,14 @@ func GetRandomCommand(c *gin.Context) {
 func GetCount(c *gin.Context) {
 	count, err := services.GetCount()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "查询失败",
-		})
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"count": count,
+		"success": true,
+		"code":    codeOK,
+		"count":   count,
 	})
 }
 
@@ -101,24 +86,25 @@ func ReportInvalid(c *gin.Context) {
 	var req UploadCommandRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "参数错误",
-		})
+		respondError(c, services.ErrEmptyContent)
 		return
 	}
 
-	err := services.MarkAsInvalid(req.Content)
+	quarantined, err := services.ReportInvalid(req.Content, identity.FromContext(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": err.Error(),
-		})
+		respondError(c, err)
 		return
 	}
 
+	message := "已收到举报，感谢反馈"
+	if quarantined {
+		message = "举报人数已达上限，该口令已被隐藏"
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "已标记为无效",
+		"success":     true,
+		"code":        codeOK,
+		"message":     message,
+		"quarantined": quarantined,
 	})
 }
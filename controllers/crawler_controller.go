@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"net/http"
+	"yuanbao/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetCrawlerRuns 分页查询爬虫执行记录，支持按 source / status 过滤
+func GetCrawlerRuns(c *gin.Context) {
+	page, pageSize := getPagination(c)
+
+	runs, total, err := services.ListCrawlerRuns(c.Query("source"), c.Query("status"), page, pageSize)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"code":     codeOK,
+		"items":    runs,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+	})
+}
+
+// GetCrawlerStatus 查询各采集来源的最近执行情况
+func GetCrawlerStatus(c *gin.Context) {
+	statuses, err := services.GetCrawlerStatus()
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	items := make([]gin.H, 0, len(statuses))
+	for _, status := range statuses {
+		items = append(items, gin.H{
+			"source":         status.Name,
+			"schedule":       status.Schedule.String(),
+			"stale":          status.Stale,
+			"emptyRuns":      status.EmptyRuns,
+			"lastRun":        status.LastRun,
+			"lastSuccess":    status.LastSuccess,
+			"lastProductive": status.LastProductive,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"items":   items,
+	})
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"yuanbao/scheduler"
+	"yuanbao/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 所有 JSON 响应都带有 code 字段：成功时为 ok，失败时为下表中的错误码，客户端应按 code 而不是 message 判断
+const (
+	codeOK       = "ok"
+	codeInternal = "internal"
+)
+
+// errorResponse 业务错误对应的错误码、HTTP状态码和提示文案
+// 带 LimitError 详情的错误，提示文案中的 %v 替换为限制值（时长格式化为中文，见 formatDuration）
+type errorResponse struct {
+	err     error
+	code    string
+	status  int
+	message string
+}
+
+// errorResponses 业务错误到响应的映射，按顺序匹配第一个 errors.Is 成立的项
+var errorResponses = []errorResponse{
+	{services.ErrInvalidInput, "invalid_input", http.StatusBadRequest, "参数错误"},
+	{services.ErrEmptyContent, "empty_content", http.StatusBadRequest, "口令内容不能为空"},
+	{services.ErrTooShort, "too_short", http.StatusBadRequest, "口令长度不能少于%v个字符"},
+	{services.ErrTooLong, "too_long", http.StatusBadRequest, "口令长度不能超过%v个字符"},
+	{services.ErrContainsLink, "contains_link", http.StatusBadRequest, "口令不能包含链接"},
+	{services.ErrExpired, "expired", http.StatusBadRequest, "过期时间必须晚于当前时间"},
+	{services.ErrExpiryTooFar, "expiry_too_far", http.StatusBadRequest, "过期时间不能晚于%v之后"},
+	{services.ErrDuplicate, "duplicate", http.StatusConflict, "该口令已存在，请勿重复提交"},
+	{services.ErrNotFound, "not_found", http.StatusNotFound, "口令不存在"},
+	{services.ErrPoolEmpty, "pool_empty", http.StatusNotFound, "暂无可用口令"},
+	{services.ErrInvalidDisplay, "invalid_display", http.StatusBadRequest, "展示上限必须大于0，展示次数不能为负数"},
+	{services.ErrSourceNotFound, "source_not_found", http.StatusNotFound, "未知的采集来源"},
+	{services.ErrClaimNotFound, "claim_not_found", http.StatusNotFound, "领取记录不存在"},
+	{services.ErrClaimFinished, "claim_finished", http.StatusConflict, "该领取已结束，请勿重复操作"},
+	{services.ErrClaimExpired, "claim_expired", http.StatusGone, "领取已超时，请重新获取"},
+	{services.ErrInvalidOutcome, "invalid_outcome", http.StatusBadRequest, "反馈结果不合法"},
+	{services.ErrFeedbackExists, "feedback_exists", http.StatusConflict, "已反馈过该口令，请勿重复提交"},
+	{services.ErrQuarantined, "quarantined", http.StatusConflict, "该口令已被隐藏，等待审核"},
+	{services.ErrOwnCommand, "own_command", http.StatusForbidden, "不能举报自己上传的口令"},
+	{services.ErrAlreadyReported, "already_reported", http.StatusConflict, "您已举报过该口令"},
+	{services.ErrInvalidTransition, "invalid_transition", http.StatusConflict, "口令当前状态不允许该操作"},
+	{scheduler.ErrJobNotFound, "job_not_found", http.StatusNotFound, "定时任务不存在"},
+	{scheduler.ErrJobRunning, "job_running", http.StatusConflict, "定时任务正在执行"},
+	{scheduler.ErrStopped, "scheduler_stopped", http.StatusServiceUnavailable, "服务正在停止"},
+}
+
+// durationUnits 提示文案中时长的单位，从大到小
+var durationUnits = []struct {
+	unit time.Duration
+	name string
+}{
+	{24 * time.Hour, "天"},
+	{time.Hour, "小时"},
+	{time.Minute, "分钟"},
+	{time.Second, "秒"},
+}
+
+// formatDuration 将时长格式化为中文，如 168h 为“7天”、90m 为“1小时30分钟”，不足1秒的部分舍去
+func formatDuration(d time.Duration) string {
+	var b strings.Builder
+	for _, u := range durationUnits {
+		if n := d / u.unit; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, u.name)
+			d -= n * u.unit
+		}
+	}
+	if b.Len() == 0 {
+		return "0秒"
+	}
+	return b.String()
+}
+
+// respondError 按 errorResponses 输出错误响应，未知错误记录日志并返回 500
+func respondError(c *gin.Context, err error) {
+	for _, r := range errorResponses {
+		if !errors.Is(err, r.err) {
+			continue
+		}
+
+		message := r.message
+		var limit *services.LimitError
+		if errors.As(err, &limit) {
+			value := limit.Limit
+			if d, ok := value.(time.Duration); ok {
+				value = formatDuration(d)
+			}
+			message = fmt.Sprintf(message, value)
+		}
+		body := gin.H{
+			"success": false,
+			"code":    r.code,
+			"message": message,
+		}
+		var dup *services.DuplicateError
+		if errors.As(err, &dup) {
+			body["existingId"] = dup.ExistingID
+		}
+		c.JSON(r.status, body)
+		return
+	}
+
+	log.Printf("%s %s 处理失败: %v", c.Request.Method, c.FullPath(), err)
+	c.JSON(http.StatusInternalServerError, gin.H{
+		"success": false,
+		"code":    codeInternal,
+		"message": "服务器内部错误",
+	})
+}
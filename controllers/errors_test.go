@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	cases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{7 * 24 * time.Hour, "7天"},
+		{90 * time.Minute, "1小时30分钟"},
+		{25*time.Hour + 30*time.Second, "1天1小时30秒"},
+		{45 * time.Second, "45秒"},
+		{500 * time.Millisecond, "0秒"},
+	}
+	for _, c := range cases {
+		if got := formatDuration(c.d); got != c.want {
+			t.Errorf("formatDuration(%v) = %q, want %q", c.d, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+	"yuanbao/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListJobs 查询所有定时任务及下次执行时间
+func ListJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"items":   services.ListJobs(),
+	})
+}
+
+// RunJob 立即执行定时任务
+func RunJob(c *gin.Context) {
+	if err := services.RunJob(c.Param("name")); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": "任务已在后台启动",
+	})
+}
+
+// EnableJob 启用定时任务
+func EnableJob(c *gin.Context) {
+	setJobEnabled(c, true)
+}
+
+// DisableJob 禁用定时任务（仍可手动执行）
+func DisableJob(c *gin.Context) {
+	setJobEnabled(c, false)
+}
+
+// setJobEnabled 修改定时任务启用状态
+func setJobEnabled(c *gin.Context, enabled bool) {
+	if err := services.SetJobEnabled(c.Param("name"), enabled); err != nil {
+		respondError(c, err)
+		return
+	}
+
+	message := "任务已禁用"
+	if enabled {
+		message = "任务已启用"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"code":    codeOK,
+		"message": message,
+	})
+}
@@ -0,0 +1,48 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+)
+
+// userAgent 模拟桌面浏览器
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// randomCookie 随机选择一个Cookie
+func (c *TiebaCrawler) randomCookie() string {
+	return c.cfg.Cookies[rand.Intn(len(c.cfg.Cookies))]
+}
+
+// fetch 请求页面并返回HTML
+func (c *TiebaCrawler) fetch(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", &FetchError{URL: url, Err: err}
+	}
+
+	// 不手动设置 Accept-Encoding，由 http.Transport 自动处理 gzip 解压
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8")
+	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
+	req.Header.Set("Referer", "https://tieba.baidu.com/")
+	req.Header.Set("Upgrade-Insecure-Requests", "1")
+	req.Header.Set("Cookie", c.randomCookie())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", &FetchError{URL: url, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &FetchError{URL: url, StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", &FetchError{URL: url, StatusCode: resp.StatusCode, Err: err}
+	}
+	return string(body), nil
+}
@@ -0,0 +1,91 @@
+// Package crawler 口令采集来源
+//
+// 所有来源实现 Source 接口并注册到 Registry，内置来源包括：
+//   - 百度贴吧单帖子 / 首页：从帖子最后一页开始倒序逐楼检查发帖时间，只采集时间阈值内的新楼层；
+//     整页都在阈值内时继续爬取上一页，遇到超时楼层立即停止
+//   - 本地目录：读取投放到目录中的文本文件，每行一个口令
+//   - RSS/Atom 订阅：读取订阅条目的正文
+package crawler
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+	"yuanbao/parser"
+)
+
+// Config 爬虫配置
+type Config struct {
+	Cookies        []string      // Cookie列表，每次请求随机选择一个
+	TimeThreshold  time.Duration // 只采集该时间内的楼层
+	MaxThreads     int           // 方案2最多爬取的帖子数
+	RequestTimeout time.Duration // 单次请求超时
+	MinLength      int           // 原文最小长度（字节）
+	MinCodeLength  int           // 口令本体最小长度（字节）
+	MaxLength      int           // 口令最大长度（字节）
+}
+
+// Post 楼层
+type Post struct {
+	Content  string    // 楼层文本
+	TimeText string    // 原始时间文本
+	PostTime time.Time // 解析后的发帖时间，无法解析时为零值
+}
+
+// Thread 帖子及其采集到的口令
+type Thread struct {
+	Title    string
+	URL      string
+	Commands []Post
+	Err      error // 爬取该帖子失败的原因，成功时为nil
+}
+
+// TiebaCrawler 贴吧爬虫，可被多个来源并发使用
+type TiebaCrawler struct {
+	cfg    Config
+	client *http.Client
+}
+
+// New 创建贴吧爬虫
+func New(cfg Config) (*TiebaCrawler, error) {
+	if len(cfg.Cookies) == 0 {
+		return nil, ErrNoCookies
+	}
+
+	return &TiebaCrawler{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.RequestTimeout},
+	}, nil
+}
+
+// randomDelay 10-20秒随机间隔，降低被反爬识别的概率
+func (c *TiebaCrawler) randomDelay() time.Duration {
+	return 10*time.Second + time.Duration(rand.Int63n(int64(10*time.Second)))
+}
+
+// IsValidCommand 验证口令：原文长度在范围内，解析出的口令本体不短于下限且不包含链接
+// 分隔符或标签格式的分享文案中，口令以外的链接和引导语不影响判断
+func (c *TiebaCrawler) IsValidCommand(content string) bool {
+	code := parser.Parse(content).Code
+	if len(content) < c.cfg.MinLength || len(content) > c.cfg.MaxLength || len(code) < c.cfg.MinCodeLength {
+		return false
+	}
+
+	lower := strings.ToLower(code)
+	return !strings.Contains(lower, "http://") && !strings.Contains(lower, "https://")
+}
+
+// sleep 等待指定时间，context 取消时提前返回
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
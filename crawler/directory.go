@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// processedSuffix 已导入文件的后缀，避免重复导入
+const processedSuffix = ".done"
+
+// directorySource 从本地目录导入口令
+// 目录中的每个 .txt 文件每行一个口令，导入后重命名为 *.txt.done
+type directorySource struct {
+	name     string
+	dir      string
+	schedule time.Duration
+}
+
+// NewDirectorySource 创建本地目录采集来源
+func NewDirectorySource(name, dir string, schedule time.Duration) Source {
+	return &directorySource{name: name, dir: dir, schedule: schedule}
+}
+
+func (s *directorySource) Name() string            { return s.name }
+func (s *directorySource) Schedule() time.Duration { return s.schedule }
+
+func (s *directorySource) Fetch(ctx context.Context) ([]Harvested, error) {
+	if _, err := os.Stat(s.dir); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	var harvested []Harvested
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return harvested, err
+		}
+
+		lines, err := readLines(file)
+		if err != nil {
+			return harvested, err
+		}
+		// 先标记为已导入，再交给调用方保存，避免重命名失败时反复导入
+		if err := os.Rename(file, file+processedSuffix); err != nil {
+			return harvested, err
+		}
+
+		origin := "file://" + filepath.ToSlash(file)
+		if abs, err := filepath.Abs(file); err == nil {
+			origin = "file://" + filepath.ToSlash(abs)
+		}
+		for _, line := range lines {
+			harvested = append(harvested, Harvested{Content: line, OriginURL: origin})
+		}
+	}
+
+	return harvested, nil
+}
+
+// readLines 读取文件中的非空行
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoCookies 未配置Cookie，贴吧未登录时无法正常访问
+	ErrNoCookies = errors.New("未配置贴吧Cookie")
+	// ErrThreadListNotFound 首页中找不到帖子列表，通常是页面结构变化或被反爬拦截
+	ErrThreadListNotFound = errors.New("未找到帖子列表")
+)
+
+// FetchError 页面请求失败
+type FetchError struct {
+	URL        string
+	StatusCode int   // HTTP状态码，网络错误时为0
+	Err        error // 底层错误，HTTP状态码异常时为nil
+}
+
+func (e *FetchError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("请求 %s 失败: %v", e.URL, e.Err)
+	}
+	return fmt.Sprintf("请求 %s 失败: HTTP %d", e.URL, e.StatusCode)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// ParseError 页面解析失败
+type ParseError struct {
+	URL string
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("解析 %s 失败: %v", e.URL, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
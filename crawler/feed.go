@@ -0,0 +1,146 @@
+package crawler
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// feedDocument 同时兼容 RSS 2.0（channel/item）和 Atom（entry）
+type feedDocument struct {
+	Items   []feedItem  `xml:"channel>item"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type feedItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type atomEntry struct {
+	Title     string `xml:"title"`
+	Content   string `xml:"content"`
+	Summary   string `xml:"summary"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Links     []struct {
+		Href string `xml:"href,attr"`
+	} `xml:"link"`
+}
+
+// feedTimeLayouts 订阅中常见的时间格式
+var feedTimeLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700"}
+
+// feedSource 从 RSS/Atom 订阅导入口令，每个条目的正文（无正文时取标题）作为一条口令
+type feedSource struct {
+	name     string
+	url      string
+	schedule time.Duration
+	maxAge   time.Duration
+	client   *http.Client
+}
+
+// NewFeedSource 创建订阅采集来源，只导入 maxAge 内发布的条目（无发布时间的条目总是导入）
+func NewFeedSource(name, url string, schedule, maxAge, timeout time.Duration) Source {
+	return &feedSource{
+		name:     name,
+		url:      url,
+		schedule: schedule,
+		maxAge:   maxAge,
+		client:   &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *feedSource) Name() string            { return s.name }
+func (s *feedSource) Schedule() time.Duration { return s.schedule }
+
+func (s *feedSource) Fetch(ctx context.Context) ([]Harvested, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, &FetchError{URL: s.url, Err: err}
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, &FetchError{URL: s.url, Err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &FetchError{URL: s.url, StatusCode: resp.StatusCode}
+	}
+
+	var doc feedDocument
+	if err := xml.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, &ParseError{URL: s.url, Err: err}
+	}
+
+	now := time.Now()
+	var harvested []Harvested
+	add := func(title, body, link, published string) {
+		content := htmlText(body)
+		if content == "" {
+			content = htmlText(title)
+		}
+		if content == "" {
+			return
+		}
+
+		postedAt := parseFeedTime(published)
+		if !postedAt.IsZero() && now.Sub(postedAt) > s.maxAge {
+			return
+		}
+		if link == "" {
+			link = s.url
+		}
+		harvested = append(harvested, Harvested{Content: content, OriginURL: link, PostedAt: postedAt})
+	}
+
+	for _, item := range doc.Items {
+		add(item.Title, item.Description, item.Link, item.PubDate)
+	}
+	for _, entry := range doc.Entries {
+		body := entry.Content
+		if body == "" {
+			body = entry.Summary
+		}
+		published := entry.Published
+		if published == "" {
+			published = entry.Updated
+		}
+		var link string
+		if len(entry.Links) > 0 {
+			link = entry.Links[0].Href
+		}
+		add(entry.Title, body, link, published)
+	}
+
+	return harvested, nil
+}
+
+// htmlText 提取HTML片段中的纯文本
+func htmlText(fragment string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(fragment))
+	if err != nil {
+		return strings.TrimSpace(fragment)
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+// parseFeedTime 解析订阅条目时间，无法解析时返回零值
+func parseFeedTime(text string) time.Time {
+	text = strings.TrimSpace(text)
+	for _, layout := range feedTimeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
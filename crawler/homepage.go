@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// homepageDelay 首页模式下同一帖子跨页的间隔
+const homepageDelay = 2 * time.Second
+
+// threadListPattern 帖子列表被放在注释包裹的 code 标签中，需要先提取出来再解析
+var threadListPattern = regexp.MustCompile(`(?s)<code class="pagelet_html" id="pagelet_html_frs-list/pagelet/thread_list"[^>]*>(.*?)</code>`)
+
+// CrawlHomepage 方案2：爬取贴吧首页前 MaxThreads 个帖子中时间阈值内的口令
+// 单个帖子失败记录在 Thread.Err 中，不影响其他帖子
+func (c *TiebaCrawler) CrawlHomepage(ctx context.Context, homepageURL string) ([]Thread, error) {
+	start := time.Now()
+
+	threads, err := c.homepageThreads(ctx, homepageURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(threads) > c.cfg.MaxThreads {
+		threads = threads[:c.cfg.MaxThreads]
+	}
+	log.Printf("共找到 %d 个帖子（最多爬取 %d 个）", len(threads), c.cfg.MaxThreads)
+
+	for i := range threads {
+		thread := &threads[i]
+		log.Printf("[%d/%d] 正在爬取: %s", i+1, len(threads), thread.URL)
+
+		thread.Commands, thread.Err = c.crawlThread(ctx, thread.URL, start, func() time.Duration { return homepageDelay })
+		if thread.Err != nil {
+			log.Printf("  爬取失败: %v", thread.Err)
+		} else {
+			log.Printf("  获取 %d 个有效口令", len(thread.Commands))
+		}
+
+		// 帖子间隔
+		if i < len(threads)-1 {
+			if err := sleep(ctx, c.randomDelay()); err != nil {
+				return threads[:i+1], err
+			}
+		}
+	}
+
+	return threads, nil
+}
+
+// homepageThreads 获取贴吧首页的帖子列表
+func (c *TiebaCrawler) homepageThreads(ctx context.Context, homepageURL string) ([]Thread, error) {
+	body, err := c.fetch(ctx, homepageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	match := threadListPattern.FindStringSubmatch(body)
+	if match == nil {
+		return nil, ErrThreadListNotFound
+	}
+	listHTML := strings.NewReplacer("<!--", "", "-->", "").Replace(match[1])
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(listHTML))
+	if err != nil {
+		return nil, &ParseError{URL: homepageURL, Err: err}
+	}
+
+	list := doc.Find("ul#thread_list")
+	if list.Length() == 0 {
+		return nil, ErrThreadListNotFound
+	}
+
+	var threads []Thread
+	list.Find("li.j_thread_list").Each(func(_ int, item *goquery.Selection) {
+		link := item.Find("a.j_th_tit").First()
+		title := strings.TrimSpace(link.AttrOr("title", ""))
+		href := link.AttrOr("href", "")
+		if strings.HasPrefix(href, "/p/") {
+			href = "https://tieba.baidu.com" + href
+		}
+		if title != "" && href != "" {
+			threads = append(threads, Thread{Title: title, URL: href})
+		}
+	})
+	return threads, nil
+}
@@ -0,0 +1,18 @@
+package crawler
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// collectText 深度优先收集文本节点，每段去除首尾空白后直接拼接
+func collectText(node *html.Node, b *strings.Builder) {
+	if node.Type == html.TextNode {
+		b.WriteString(strings.TrimSpace(node.Data))
+		return
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		collectText(child, b)
+	}
+}
@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Harvested 采集到的一条口令及其出处
+type Harvested struct {
+	Content   string    // 口令内容
+	OriginURL string    // 出处地址（帖子、文件或订阅条目）
+	PostedAt  time.Time // 发布时间，未知时为零值
+}
+
+// Source 口令采集来源，每个来源有独立的名称和执行间隔
+type Source interface {
+	// Name 来源名称，同时作为口令的 origin 记录
+	Name() string
+	// Schedule 定时执行间隔
+	Schedule() time.Duration
+	// Fetch 执行一次采集；出错时仍可返回已采集的部分结果
+	Fetch(ctx context.Context) ([]Harvested, error)
+}
+
+// Registry 采集来源注册表，按注册顺序返回
+type Registry struct {
+	mu      sync.RWMutex
+	sources map[string]Source
+	order   []string
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{sources: make(map[string]Source)}
+}
+
+// Register 注册来源，名称重复时返回错误
+func (r *Registry) Register(src Source) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := src.Name()
+	if _, ok := r.sources[name]; ok {
+		return fmt.Errorf("采集来源重复注册: %s", name)
+	}
+	r.sources[name] = src
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Get 按名称查找来源
+func (r *Registry) Get(name string) (Source, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	src, ok := r.sources[name]
+	return src, ok
+}
+
+// All 返回所有来源
+func (r *Registry) All() []Source {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	all := make([]Source, 0, len(r.order))
+	for _, name := range r.order {
+		all = append(all, r.sources[name])
+	}
+	return all
+}
@@ -0,0 +1,195 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var pageNumberPattern = regexp.MustCompile(`pn=(\d+)`)
+
+// CrawlThread 方案1：爬取单个帖子中时间阈值内的口令
+func (c *TiebaCrawler) CrawlThread(ctx context.Context, threadURL string) ([]Post, error) {
+	return c.crawlThread(ctx, threadURL, time.Now(), c.randomDelay)
+}
+
+// crawlThread 从最后一页开始倒序爬取帖子，遇到超时楼层停止
+func (c *TiebaCrawler) crawlThread(ctx context.Context, threadURL string, start time.Time, pageDelay func() time.Duration) ([]Post, error) {
+	lastPage, err := c.lastPage(ctx, threadURL)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("帖子最后一页: %d", lastPage)
+
+	var commands []Post
+	for page := lastPage; page >= 1; page-- {
+		posts, err := c.crawlPage(ctx, threadURL, page)
+		if err != nil {
+			return commands, err
+		}
+		if len(posts) == 0 {
+			log.Printf("第 %d 页无数据，停止爬取", page)
+			break
+		}
+
+		// 倒序遍历（从最后一楼到第一楼）
+		pageAllValid := true
+		for i := len(posts) - 1; i >= 0; i-- {
+			post := posts[i]
+
+			if post.PostTime.IsZero() {
+				log.Printf("  [SKIP] 无法解析时间: %s", post.TimeText)
+				pageAllValid = false
+				break
+			}
+			if start.Sub(post.PostTime) > c.cfg.TimeThreshold {
+				log.Printf("  [SKIP] 超时: %s (相差 %.1f 分钟)", post.TimeText, start.Sub(post.PostTime).Minutes())
+				pageAllValid = false
+				break
+			}
+
+			if c.IsValidCommand(post.Content) {
+				commands = append(commands, post)
+				log.Printf("  [OK] 加入: %s - %s...", post.TimeText, preview(post.Content))
+			} else {
+				log.Printf("  [X] 内容不符合要求: %s...", preview(post.Content))
+			}
+		}
+
+		// 该页所有楼层都满足条件，继续爬取上一页
+		if !pageAllValid || page == 1 {
+			break
+		}
+		log.Printf("第 %d 页所有楼层都在时间范围内，继续爬取上一页", page)
+		if err := sleep(ctx, pageDelay()); err != nil {
+			return commands, err
+		}
+	}
+
+	return commands, nil
+}
+
+// lastPage 获取帖子的最后一页页码
+func (c *TiebaCrawler) lastPage(ctx context.Context, threadURL string) (int, error) {
+	doc, err := c.fetchDocument(ctx, threadURL)
+	if err != nil {
+		return 0, err
+	}
+
+	last := 1
+	doc.Find("li.l_pager a").Each(func(_ int, link *goquery.Selection) {
+		href, _ := link.Attr("href")
+		if match := pageNumberPattern.FindStringSubmatch(href); match != nil {
+			if n, err := strconv.Atoi(match[1]); err == nil && n > last {
+				last = n
+			}
+		}
+	})
+	return last, nil
+}
+
+// crawlPage 爬取指定页的所有楼层
+func (c *TiebaCrawler) crawlPage(ctx context.Context, threadURL string, page int) ([]Post, error) {
+	pageURL := threadURL
+	if page > 1 {
+		u, err := url.Parse(threadURL)
+		if err != nil {
+			return nil, &ParseError{URL: threadURL, Err: err}
+		}
+		query := u.Query()
+		query.Set("pn", strconv.Itoa(page))
+		u.RawQuery = query.Encode()
+		pageURL = u.String()
+	}
+
+	doc, err := c.fetchDocument(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var posts []Post
+	doc.Find("div.l_post").Each(func(_ int, node *goquery.Selection) {
+		contentDiv := node.Find("div.d_post_content").First()
+		if contentDiv.Length() == 0 {
+			return
+		}
+
+		post := Post{
+			Content:  strippedText(contentDiv),
+			TimeText: postTimeText(node),
+		}
+		if post.TimeText != "" {
+			if t, ok := ParsePostTime(post.TimeText, now); ok {
+				post.PostTime = t
+			}
+		}
+		posts = append(posts, post)
+	})
+
+	return posts, nil
+}
+
+// postTimeText 获取楼层发帖时间文本：优先 data-field 中的 content.date，其次 tail-info
+func postTimeText(node *goquery.Selection) string {
+	if raw, ok := node.Attr("data-field"); ok {
+		var field struct {
+			Content struct {
+				Date string `json:"date"`
+			} `json:"content"`
+		}
+		if json.Unmarshal([]byte(raw), &field) == nil && field.Content.Date != "" {
+			return field.Content.Date
+		}
+	}
+
+	var text string
+	node.Find("div.post-tail-wrap span.tail-info").EachWithBreak(func(_ int, span *goquery.Selection) bool {
+		t := strings.TrimSpace(span.Text())
+		if strings.ContainsAny(t, ":-") || strings.Contains(t, "前") {
+			text = t
+			return false
+		}
+		return true
+	})
+	return text
+}
+
+// fetchDocument 请求页面并解析为DOM
+func (c *TiebaCrawler) fetchDocument(ctx context.Context, pageURL string) (*goquery.Document, error) {
+	body, err := c.fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		return nil, &ParseError{URL: pageURL, Err: err}
+	}
+	return doc, nil
+}
+
+// strippedText 拼接所有文本节点并去除各自首尾空白
+func strippedText(sel *goquery.Selection) string {
+	var b strings.Builder
+	for _, node := range sel.Nodes {
+		collectText(node, &b)
+	}
+	return b.String()
+}
+
+// preview 截取内容前30个字符用于日志
+func preview(content string) string {
+	runes := []rune(content)
+	if len(runes) > 30 {
+		return string(runes[:30])
+	}
+	return content
+}
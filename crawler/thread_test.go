@@ -0,0 +1,204 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixturePost 测试页面中的一个楼层
+type fixturePost struct {
+	content string
+	date    string // data-field 中的 content.date，为空时不设置
+	tail    string // tail-info 中的时间文本
+}
+
+// threadPage 生成贴吧帖子页面，lastPage > 1 时带分页链接
+func threadPage(lastPage int, posts []fixturePost) string {
+	var b strings.Builder
+	b.WriteString("<html><body>")
+	if lastPage > 1 {
+		b.WriteString(`<ul><li class="l_pager">`)
+		for p := 2; p <= lastPage; p++ {
+			fmt.Fprintf(&b, `<a href="/p/1?pn=%d">%d</a>`, p, p)
+		}
+		b.WriteString(`</li></ul>`)
+	}
+	for _, p := range posts {
+		field := "{}"
+		if p.date != "" {
+			field = fmt.Sprintf(`{&quot;content&quot;:{&quot;date&quot;:&quot;%s&quot;}}`, p.date)
+		}
+		fmt.Fprintf(&b, `<div class="l_post" data-field="%s">`, field)
+		fmt.Fprintf(&b, `<div class="d_post_content">  %s  </div>`, p.content)
+		fmt.Fprintf(&b, `<div class="post-tail-wrap"><span class="tail-info">来自Android客户端</span><span class="tail-info">%s</span></div>`, p.tail)
+		b.WriteString(`</div>`)
+	}
+	// 没有正文的楼层（如广告）被跳过
+	b.WriteString(`<div class="l_post"><div class="ad">广告</div></div>`)
+	b.WriteString("</body></html>")
+	return b.String()
+}
+
+// newThreadServer 按 pn 参数返回对应页面，记录请求的页码和 Cookie
+func newThreadServer(t *testing.T, pages map[string]string, requested *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Cookie") != "BDUSS=test" {
+			t.Errorf("request without the configured cookie: %q", r.Header.Get("Cookie"))
+		}
+		pn := r.URL.Query().Get("pn")
+		*requested = append(*requested, pn)
+		page, ok := pages[pn]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestCrawler(t *testing.T) *TiebaCrawler {
+	t.Helper()
+	c, err := New(Config{
+		Cookies:        []string{"BDUSS=test"},
+		TimeThreshold:  20 * time.Minute,
+		RequestTimeout: 5 * time.Second,
+		MinLength:      10,
+		MinCodeLength:  4,
+		MaxLength:      500,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestCrawlPage(t *testing.T) {
+	var requested []string
+	server := newThreadServer(t, map[string]string{
+		"3": threadPage(1, []fixturePost{
+			{content: "新春红包口令AAAA1111", date: "2026-02-06 18:30", tail: "2026-02-06 18:00"},
+			{content: "新春红包口令BBBB2222", tail: "5分钟前"},
+			{content: "新春红包口令CCCC3333", tail: "刚刚"},
+		}),
+	}, &requested)
+
+	before := time.Now()
+	posts, err := newTestCrawler(t).crawlPage(context.Background(), server.URL+"/p/1", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requested) != 1 || requested[0] != "3" {
+		t.Errorf("requested pages %v, want [3]", requested)
+	}
+	if len(posts) != 3 {
+		t.Fatalf("got %d posts, want 3", len(posts))
+	}
+
+	// data-field 优先于 tail-info
+	if posts[0].Content != "新春红包口令AAAA1111" || posts[0].TimeText != "2026-02-06 18:30" {
+		t.Errorf("post 0 = %+v", posts[0])
+	}
+	if want := time.Date(2026, 2, 6, 18, 30, 0, 0, time.Local); !posts[0].PostTime.Equal(want) {
+		t.Errorf("post 0 time = %v, want %v", posts[0].PostTime, want)
+	}
+	if posts[1].TimeText != "5分钟前" {
+		t.Errorf("post 1 time text = %q, want 5分钟前", posts[1].TimeText)
+	}
+	if ago := before.Sub(posts[1].PostTime); ago < 4*time.Minute || ago > 6*time.Minute {
+		t.Errorf("post 1 was posted %v ago, want about 5m", ago)
+	}
+	// 不像时间的 tail-info 被忽略，发帖时间为零值
+	if posts[2].TimeText != "" || !posts[2].PostTime.IsZero() {
+		t.Errorf("post 2 = %+v, want no time", posts[2])
+	}
+}
+
+func TestCrawlPageHTTPError(t *testing.T) {
+	var requested []string
+	server := newThreadServer(t, map[string]string{}, &requested)
+
+	_, err := newTestCrawler(t).crawlPage(context.Background(), server.URL+"/p/1", 1)
+	fetchErr, ok := err.(*FetchError)
+	if !ok || fetchErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("crawlPage = %v, want FetchError with HTTP 404", err)
+	}
+}
+
+func TestCrawlThread(t *testing.T) {
+	start := time.Now()
+	old := start.Add(-2 * time.Hour).Format("2006-01-02 15:04")
+
+	var requested []string
+	server := newThreadServer(t, map[string]string{
+		// 第1页请求不带 pn 参数
+		"": threadPage(3, []fixturePost{
+			{content: "第一页的口令不会被请求", tail: "1分钟前"},
+		}),
+		"2": threadPage(3, []fixturePost{
+			{content: "两小时前的旧口令DDDD4444", date: old},
+			{content: "新春红包口令CCCC3333", tail: "15分钟前"},
+		}),
+		"3": threadPage(3, []fixturePost{
+			{content: "新春红包口令AAAA1111", tail: "5分钟前"},
+			{content: "太短", tail: "3分钟前"},
+			{content: "口令 https://example.com/x", tail: "2分钟前"},
+			{content: "新春红包口令BBBB2222", tail: "1分钟前"},
+		}),
+	}, &requested)
+
+	delays := 0
+	posts, err := newTestCrawler(t).crawlThread(context.Background(), server.URL+"/p/1", start, func() time.Duration {
+		delays++
+		return 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, p := range posts {
+		got = append(got, p.Content)
+	}
+	// 最后一页倒序，整页都在阈值内时继续上一页，遇到超时楼层停止
+	want := []string{"新春红包口令BBBB2222", "新春红包口令AAAA1111", "新春红包口令CCCC3333"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("commands = %v, want %v", got, want)
+	}
+	if strings.Join(requested, ",") != ",3,2" {
+		t.Errorf("requested pages %q, want first page, then 3 and 2", requested)
+	}
+	if delays != 1 {
+		t.Errorf("waited %d times between pages, want 1", delays)
+	}
+}
+
+func TestCrawlThreadCancelled(t *testing.T) {
+	var requested []string
+	server := newThreadServer(t, map[string]string{
+		"": threadPage(2, nil),
+		"2": threadPage(2, []fixturePost{
+			{content: "新春红包口令AAAA1111", tail: "1分钟前"},
+		}),
+	}, &requested)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	posts, err := newTestCrawler(t).crawlThread(ctx, server.URL+"/p/1", time.Now(), func() time.Duration {
+		cancel() // 等待翻页时取消
+		return time.Hour
+	})
+	if err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	// 取消前采集到的口令仍然返回
+	if len(posts) != 1 {
+		t.Errorf("got %d posts, want 1", len(posts))
+	}
+}
@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// threadSource 爬取单个贴吧帖子
+type threadSource struct {
+	name     string
+	url      string
+	schedule time.Duration
+	crawler  *TiebaCrawler
+}
+
+// NewThreadSource 创建单帖子采集来源（方案1）
+func NewThreadSource(name, url string, schedule time.Duration, c *TiebaCrawler) Source {
+	return &threadSource{name: name, url: url, schedule: schedule, crawler: c}
+}
+
+func (s *threadSource) Name() string            { return s.name }
+func (s *threadSource) Schedule() time.Duration { return s.schedule }
+
+func (s *threadSource) Fetch(ctx context.Context) ([]Harvested, error) {
+	posts, err := s.crawler.CrawlThread(ctx, s.url)
+	return harvestPosts(posts, s.url), err
+}
+
+// homepageSource 爬取贴吧首页的多个帖子
+type homepageSource struct {
+	name     string
+	url      string
+	schedule time.Duration
+	crawler  *TiebaCrawler
+}
+
+// NewHomepageSource 创建贴吧首页采集来源（方案2）
+func NewHomepageSource(name, url string, schedule time.Duration, c *TiebaCrawler) Source {
+	return &homepageSource{name: name, url: url, schedule: schedule, crawler: c}
+}
+
+func (s *homepageSource) Name() string            { return s.name }
+func (s *homepageSource) Schedule() time.Duration { return s.schedule }
+
+// Fetch 汇总所有帖子的口令，部分帖子失败时返回合并后的错误
+func (s *homepageSource) Fetch(ctx context.Context) ([]Harvested, error) {
+	threads, err := s.crawler.CrawlHomepage(ctx, s.url)
+
+	var harvested []Harvested
+	errs := []error{err}
+	for _, thread := range threads {
+		harvested = append(harvested, harvestPosts(thread.Commands, thread.URL)...)
+		errs = append(errs, thread.Err)
+	}
+	return harvested, errors.Join(errs...)
+}
+
+// harvestPosts 将楼层转换为采集结果
+func harvestPosts(posts []Post, url string) []Harvested {
+	harvested := make([]Harvested, 0, len(posts))
+	for _, post := range posts {
+		harvested = append(harvested, Harvested{
+			Content:   post.Content,
+			OriginURL: url,
+			PostedAt:  post.PostTime,
+		})
+	}
+	return harvested
+}
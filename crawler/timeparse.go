@@ -0,0 +1,63 @@
+package crawler
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	fullTimePattern   = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\s+\d{2}:\d{2}`)
+	clockPattern      = regexp.MustCompile(`(\d{1,2}):(\d{2})`)
+	minutesAgoPattern = regexp.MustCompile(`(\d+)\s*分钟前`)
+	hoursAgoPattern   = regexp.MustCompile(`(\d+)\s*小时前`)
+	spacesPattern     = regexp.MustCompile(`\s+`)
+)
+
+// ParsePostTime 解析贴吧时间字符串，无法解析时返回 false
+// 支持格式：
+//   - "2026-02-06 18:30"
+//   - "今天18:30" 或 "18:30"（晚于当前时间则视为昨天）
+//   - "5分钟前"
+//   - "2小时前"
+func ParsePostTime(text string, now time.Time) (time.Time, bool) {
+	// 格式1: "2026-02-06 18:30"
+	if match := fullTimePattern.FindString(text); match != "" {
+		normalized := spacesPattern.ReplaceAllString(match, " ")
+		t, err := time.ParseInLocation("2006-01-02 15:04", normalized, now.Location())
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, true
+	}
+
+	// 格式2: "今天18:30" 或 "18:30"
+	if match := clockPattern.FindStringSubmatch(text); match != nil {
+		hour, _ := strconv.Atoi(match[1])
+		minute, _ := strconv.Atoi(match[2])
+		if hour > 23 || minute > 59 {
+			return time.Time{}, false
+		}
+		t := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+
+		// 如果时间在未来，说明是昨天的
+		if t.After(now) {
+			t = t.AddDate(0, 0, -1)
+		}
+		return t, true
+	}
+
+	// 格式3: "X分钟前"
+	if match := minutesAgoPattern.FindStringSubmatch(text); match != nil {
+		minutes, _ := strconv.Atoi(match[1])
+		return now.Add(-time.Duration(minutes) * time.Minute), true
+	}
+
+	// 格式4: "X小时前"
+	if match := hoursAgoPattern.FindStringSubmatch(text); match != nil {
+		hours, _ := strconv.Atoi(match[1])
+		return now.Add(-time.Duration(hours) * time.Hour), true
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,40 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePostTime(t *testing.T) {
+	cst := time.FixedZone("CST", 8*3600)
+	now := time.Date(2026, 2, 6, 10, 0, 0, 0, cst)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, cst)
+	}
+
+	cases := []struct {
+		text string
+		now  time.Time
+		want time.Time
+		ok   bool
+	}{
+		{"2026-02-05 18:30", now, at(2, 5, 18, 30), true},
+		{"2026-02-05  18:30", now, at(2, 5, 18, 30), true},
+		{"今天09:30", now, at(2, 6, 9, 30), true},
+		{"09:59", now, at(2, 6, 9, 59), true},
+		// 晚于当前时间的时刻是昨天的
+		{"18:30", now, at(2, 5, 18, 30), true},
+		{"23:50", time.Date(2026, 3, 1, 0, 10, 0, 0, cst), at(2, 28, 23, 50), true},
+		{"5分钟前", now, at(2, 6, 9, 55), true},
+		{"2小时前", now, at(2, 6, 8, 0), true},
+		{"25:00", now, time.Time{}, false},
+		{"刚刚", now, time.Time{}, false},
+		{"", now, time.Time{}, false},
+	}
+	for _, c := range cases {
+		got, ok := ParsePostTime(c.text, c.now)
+		if ok != c.ok || !got.Equal(c.want) {
+			t.Errorf("ParsePostTime(%q) = %v, %v; want %v, %v", c.text, got, ok, c.want, c.ok)
+		}
+	}
+}
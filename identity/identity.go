@@ -0,0 +1,118 @@
+// Package identity 将请求来源地址解析为客户端标识
+//
+// 限流、不展示自己上传的口令、举报和反馈去重都使用同一个标识：
+// 只有可信代理转发的请求才采信 X-Forwarded-For（由 gin 的 TrustedProxies 处理），
+// IPv4 映射地址和本地回环统一为 IPv4，IPv6 地址按前缀聚合，防止用户轮换同一网段内的地址。
+// 入库和作为限流键之前，标识会用 HMAC 密钥散列，数据库中不保存原始 IP。
+package identity
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"yuanbao/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hashPrefix 散列后标识的前缀，用于和旧数据中的原始 IP 区分
+const hashPrefix = "h:"
+
+var (
+	ipv6Prefix = 64     // IPv6 聚合前缀长度
+	hashKeys   [][]byte // HMAC 密钥，第一个用于生成新标识，其余仅用于匹配轮换前的数据
+)
+
+// Init 应用标识配置，并让路由只信任配置的反向代理
+func Init(r *gin.Engine, cfg config.IdentityConfig) error {
+	ipv6Prefix = cfg.IPv6Prefix
+
+	hashKeys = hashKeys[:0]
+	for _, key := range cfg.HashKeys {
+		hashKeys = append(hashKeys, []byte(key))
+	}
+	if len(hashKeys) == 0 {
+		// 未配置密钥时使用随机密钥，重启或多实例之间标识不一致
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		hashKeys = append(hashKeys, key)
+		log.Println("警告: 未配置 identity.hash_keys，使用随机密钥，重启后无法识别之前的上传者")
+	}
+
+	return r.SetTrustedProxies(cfg.TrustedProxies)
+}
+
+// FromContext 返回请求的客户端标识（使用当前密钥散列）
+func FromContext(c *gin.Context) string {
+	return hash(hashKeys[0], Resolve(c.ClientIP()))
+}
+
+// AliasesFromContext 返回请求的客户端在所有密钥下的标识，第一个与 FromContext 相同
+// 用于匹配密钥轮换前写入的数据
+func AliasesFromContext(c *gin.Context) []string {
+	return aliases(Resolve(c.ClientIP()))
+}
+
+// Lookup 返回 IP 地址在所有密钥下的标识，供管理后台按 IP 查询
+// 传入的已经是散列后的标识时原样返回
+func Lookup(ip string) []string {
+	if strings.HasPrefix(ip, hashPrefix) {
+		return []string{ip}
+	}
+	return aliases(Resolve(ip))
+}
+
+// Hash 返回 IP 地址使用当前密钥散列后的标识，已经是散列后的标识时原样返回
+// 用于迁移旧数据中保存的原始 IP
+func Hash(ip string) string {
+	if strings.HasPrefix(ip, hashPrefix) {
+		return ip
+	}
+	return hash(hashKeys[0], Resolve(ip))
+}
+
+// aliases 计算 key 在所有密钥下的散列
+func aliases(key string) []string {
+	result := make([]string, 0, len(hashKeys))
+	for _, k := range hashKeys {
+		result = append(result, hash(k, key))
+	}
+	return result
+}
+
+// hash 计算 HMAC-SHA256，截取前24字节编码（共34个字符，可放入 varchar(50) 的 IP 列）
+func hash(key []byte, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return hashPrefix + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:24])
+}
+
+// Resolve 将 IP 地址转换为客户端标识
+// IPv4 原样返回，IPv6 返回所在网段（如 2001:db8:1:2::/64），无法解析时原样返回
+func Resolve(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ip
+	}
+
+	// 将IPv6本地地址和 IPv4 映射地址转换为IPv4格式
+	if parsed.IsLoopback() && parsed.To4() == nil {
+		return "127.0.0.1"
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.String()
+	}
+
+	if ipv6Prefix >= 128 {
+		return parsed.String()
+	}
+	network := parsed.Mask(net.CIDRMask(ipv6Prefix, 128))
+	return fmt.Sprintf("%s/%d", network, ipv6Prefix)
+}
@@ -0,0 +1,63 @@
+package identity
+
+import "testing"
+
+func TestResolve(t *testing.T) {
+	defer func(prefix int) { ipv6Prefix = prefix }(ipv6Prefix)
+
+	tests := []struct {
+		name   string
+		prefix int
+		ip     string
+		want   string
+	}{
+		{"IPv4 原样返回", 64, "203.0.113.7", "203.0.113.7"},
+		{"IPv4 回环", 64, "127.0.0.1", "127.0.0.1"},
+		{"IPv6 按 /64 聚合", 64, "2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+		{"同一 /64 内的其他地址", 64, "2001:db8:1:2:ffff:ffff:ffff:ffff", "2001:db8:1:2::/64"},
+		{"不同 /64", 64, "2001:db8:1:3::1", "2001:db8:1:3::/64"},
+		{"IPv6 大写和省略写法", 64, "2001:DB8:0:0:1::1", "2001:db8::/64"},
+		{"按 /48 聚合", 48, "2001:db8:1:2:3:4:5:6", "2001:db8:1::/48"},
+		{"前缀 128 不聚合", 128, "2001:db8:1:2:3:4:5:6", "2001:db8:1:2:3:4:5:6"},
+		{"IPv4 映射地址", 64, "::ffff:1.2.3.4", "1.2.3.4"},
+		{"IPv4 映射地址的十六进制写法", 64, "::ffff:102:304", "1.2.3.4"},
+		{"IPv6 回环", 64, "::1", "127.0.0.1"},
+		{"IPv6 回环完整写法", 64, "0:0:0:0:0:0:0:1", "127.0.0.1"},
+		{"无法解析", 64, "not-an-ip", "not-an-ip"},
+		{"空字符串", 64, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ipv6Prefix = tt.prefix
+			if got := Resolve(tt.ip); got != tt.want {
+				t.Errorf("Resolve(%q) with /%d = %q, want %q", tt.ip, tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashAggregatesSameNetwork(t *testing.T) {
+	defer func(prefix int, keys [][]byte) { ipv6Prefix, hashKeys = prefix, keys }(ipv6Prefix, hashKeys)
+	ipv6Prefix = 64
+	hashKeys = [][]byte{[]byte("current"), []byte("previous")}
+
+	a := Hash("2001:db8:1:2::a")
+	if b := Hash("2001:db8:1:2::b"); a != b {
+		t.Errorf("addresses in one /64 hash differently: %q, %q", a, b)
+	}
+	if c := Hash("2001:db8:1:3::a"); a == c {
+		t.Error("addresses in different /64 networks share an identity")
+	}
+	if Hash("::ffff:1.2.3.4") != Hash("1.2.3.4") {
+		t.Error("IPv4-mapped address hashes differently from the IPv4 address")
+	}
+	if Hash(a) != a {
+		t.Error("Hash should return an already hashed identity unchanged")
+	}
+
+	aliases := Lookup("2001:db8:1:2::c")
+	if len(aliases) != 2 || aliases[0] != a || aliases[1] == a {
+		t.Errorf("Lookup = %v, want the current hash %q first and one alias per key", aliases, a)
+	}
+}
@@ -1,46 +1,190 @@
 package main
 
 import (
-	"time"
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"yuanbao/config"
 	"yuanbao/controllers"
+	"yuanbao/identity"
+	"yuanbao/metrics"
 	"yuanbao/middleware"
-	"yuanbao/models"
+	"yuanbao/repositories"
 	"yuanbao/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
-	// 初始化数据库
-	config.InitDB()
+	// 收到 SIGINT/SIGTERM 时取消根 context，通知服务器和后台任务退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// 自动迁移数据库表
-	config.DB.AutoMigrate(&models.Command{})
+	// 加载配置：-config 参数 > YUANBAO_CONFIG 环境变量 > 默认 config.yaml（不存在则使用默认值）
+	configPath := flag.String("config", "", "配置文件路径（YAML）")
+	flag.Parse()
+
+	path, required := *configPath, true
+	if path == "" {
+		path = os.Getenv("YUANBAO_CONFIG")
+	}
+	if path == "" {
+		path, required = "config.yaml", false
+	}
 
-	// 启动爬虫定时任务
-	services.StartCrawlerScheduler()
+	cfg, err := config.Load(path, required)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 初始化数据库
+	config.InitDB(cfg.Database.Driver, cfg.Database.DSN)
 
 	// 创建 Gin 路由
 	r := gin.Default()
 
+	// 只信任配置的反向代理转发的客户端地址；散列密钥在迁移前加载，旧数据中的原始 IP 迁移时散列
+	if err := identity.Init(r, cfg.Identity); err != nil {
+		log.Fatal("可信代理配置错误:", err)
+	}
+
+	storeOptions := repositories.StoreOptions{
+		MaxDisplayCount: cfg.Command.MaxDisplayCount,
+		DefaultTTL:      cfg.Command.DefaultTTL,
+		CrawlerTTL:      cfg.Crawler.CommandTTL,
+		SampleSize:      cfg.Selection.SampleSize,
+		HashIdentity:    identity.Hash,
+	}
+
+	// 自动迁移数据库表
+	if err := repositories.AutoMigrate(config.DB, storeOptions); err != nil {
+		log.Fatal("数据库迁移失败:", err)
+	}
+
+	// 根据驱动选择口令存储实现
+	store, err := repositories.NewCommandStore(config.DB, cfg.Database.Driver, storeOptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+	services.Init(store, cfg)
+
+	// 启动定时任务（租约回收、爬虫、清理）
+	if err := services.StartScheduler(ctx, cfg); err != nil {
+		log.Fatal("启动定时任务失败:", err)
+	}
+
+	// Prometheus 监控指标
+	if cfg.Metrics.Enabled {
+		if err := metrics.RegisterPool(services.PoolStats); err != nil {
+			log.Fatal("注册监控指标失败:", err)
+		}
+		r.Use(middleware.Metrics())
+		r.GET(cfg.Metrics.Path, gin.WrapH(promhttp.Handler()))
+	}
+
 	// 静态文件服务
 	r.Static("/static", "./static")
 	r.StaticFile("/", "./static/index.html")
 
 	// 创建限流器
-	uploadLimiter := middleware.NewRateLimiter(5, 1*time.Minute)   // 每分钟最多上传5次
-	getLimiter := middleware.NewRateLimiter(20, 1*time.Minute)     // 每分钟最多获取20次
+	var limiterStore middleware.LimiterStore
+	if cfg.RateLimit.Store == config.RateLimitStoreDatabase {
+		limiterStore = middleware.NewDBStore(store) // 多实例共享配额
+	} else {
+		limiterStore = middleware.NewMemoryStore(ctx)
+	}
+	uploadLimiter := middleware.NewRateLimiter(cfg.RateLimit.Upload, limiterStore) // 上传限流
+	getLimiter := middleware.NewRateLimiter(cfg.RateLimit.Get, limiterStore)       // 获取限流
 
 	// API 路由
 	api := r.Group("/api/commands")
 	{
 		api.POST("", uploadLimiter.Middleware("upload"), controllers.UploadCommand)
 		api.GET("/random", getLimiter.Middleware("get"), controllers.GetRandomCommand)
-		api.GET("/count", controllers.GetCount) // 统计接口不限流
+		api.GET("/count", controllers.GetCount)        // 统计接口不限流
 		api.POST("/report", controllers.ReportInvalid) // 报告无效口令
+
+		// 领取确认/归还
+		api.POST("/claims/:token/confirm", controllers.ConfirmClaim)
+		api.POST("/claims/:token/release", controllers.ReleaseClaim)
+		api.POST("/claims/:token/feedback", controllers.SubmitFeedback) // 反馈领取结果
+	}
+
+	// 爬虫执行记录与状态
+	crawlerAPI := r.Group("/api/crawler")
+	{
+		crawlerAPI.GET("/runs", controllers.GetCrawlerRuns)
+		crawlerAPI.GET("/status", controllers.GetCrawlerStatus)
+	}
+
+	// 管理接口及管理后台（需配置 admin.token 或 admin.username/password）
+	if cfg.Admin.Enabled() {
+		r.StaticFile("/admin", "./static/admin.html")
+
+		admin := r.Group("/api/admin", middleware.AdminAuth(cfg.Admin))
+		{
+			admin.GET("/commands", controllers.ListCommands)
+			admin.POST("/commands", controllers.AddCommand)
+			admin.PATCH("/commands/:id", controllers.UpdateCommand)
+			admin.GET("/commands/:id/transitions", controllers.ListCommandTransitions)
+			admin.POST("/commands/bulk-delete", controllers.BulkDeleteCommands)
+
+			admin.GET("/quarantine", controllers.ListQuarantined)
+			admin.POST("/quarantine/:id/restore", controllers.RestoreQuarantined)
+			admin.DELETE("/quarantine/:id", controllers.PurgeQuarantined)
+
+			admin.GET("/crawler/sources", controllers.ListCrawlerSources)
+			admin.POST("/crawler/:name/run", controllers.TriggerCrawler)
+
+			admin.GET("/jobs", controllers.ListJobs)
+			admin.POST("/jobs/:name/run", controllers.RunJob)
+			admin.POST("/jobs/:name/enable", controllers.EnableJob)
+			admin.POST("/jobs/:name/disable", controllers.DisableJob)
+		}
+	} else {
+		log.Println("未配置管理员凭据，管理接口已禁用")
 	}
 
 	// 启动服务器
-	r.Run(":18080")
+	srv := &http.Server{
+		Addr:    cfg.Server.Addr,
+		Handler: r,
+	}
+	go func() {
+		log.Printf("服务器启动：%s", cfg.Server.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("服务器启动失败:", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Printf("收到退出信号，最多等待%s完成进行中的请求和后台任务...", cfg.Server.ShutdownTimeout)
+
+	// 所有清理步骤共用一个超时
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// 1. 停止接收新请求，等待进行中的请求（包括领取事务）完成
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP服务关闭超时: %v", err)
+	}
+
+	// 2. 等待定时任务退出（爬虫请求已随根 context 取消）
+	if err := services.StopScheduler(shutdownCtx); err != nil {
+		log.Printf("定时任务未能在超时前结束: %v", err)
+	}
+
+	// 3. 关闭数据库连接
+	if err := config.CloseDB(); err != nil {
+		log.Printf("关闭数据库连接失败: %v", err)
+	}
+
+	log.Println("服务已退出")
 }
@@ -0,0 +1,92 @@
+// Package metrics Prometheus 监控指标
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "yuanbao"
+
+// 上传结果
+const (
+	UploadAccepted = "accepted"
+	UploadRejected = "rejected"
+)
+
+// 随机获取结果
+const (
+	FetchHit   = "hit"   // 获取到口令
+	FetchMiss  = "miss"  // 口令池为空
+	FetchError = "error" // 查询出错
+)
+
+var (
+	uploads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_total",
+		Help:      "用户上传口令次数，按结果和拒绝原因统计",
+	}, []string{"result", "reason"})
+
+	randomFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "random_fetches_total",
+		Help:      "随机获取口令次数，按选择策略统计，hit 为获取到口令，miss 为没有可发放的口令",
+	}, []string{"strategy", "result"})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "被限流拒绝的请求数",
+	}, []string{"action"})
+
+	crawlerRunDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "crawler_run_duration_seconds",
+		Help:      "采集来源单次执行耗时",
+		Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600},
+	}, []string{"source", "status"})
+
+	crawlerHarvested = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "crawler_harvested_total",
+		Help:      "采集到的口令数，按保存结果统计（saved / duplicate / error）",
+	}, []string{"source", "result"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP请求耗时，route 为路由模板",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// ObserveUpload 记录一次上传，接受时 reason 为空
+func ObserveUpload(result, reason string) {
+	uploads.WithLabelValues(result, reason).Inc()
+}
+
+// ObserveRandomFetch 记录一次随机获取
+func ObserveRandomFetch(strategy, result string) {
+	randomFetches.WithLabelValues(strategy, result).Inc()
+}
+
+// ObserveRateLimited 记录一次限流拒绝
+func ObserveRateLimited(action string) {
+	rateLimited.WithLabelValues(action).Inc()
+}
+
+// ObserveCrawlerRun 记录一次采集的耗时和保存结果
+func ObserveCrawlerRun(source, status string, duration time.Duration, saved, duplicate, failed int) {
+	crawlerRunDuration.WithLabelValues(source, status).Observe(duration.Seconds())
+	crawlerHarvested.WithLabelValues(source, "saved").Add(float64(saved))
+	crawlerHarvested.WithLabelValues(source, "duplicate").Add(float64(duplicate))
+	crawlerHarvested.WithLabelValues(source, "error").Add(float64(failed))
+}
+
+// ObserveRequest 记录一次HTTP请求耗时
+func ObserveRequest(method, route, status string, duration time.Duration) {
+	requestDuration.WithLabelValues(method, route, status).Observe(duration.Seconds())
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"log"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolStat 口令池中某个来源、某个已展示次数的可用口令数
+type PoolStat struct {
+	Source       string
+	DisplayCount int
+	Count        int64
+}
+
+// poolCollector 抓取时实时查询口令池
+type poolCollector struct {
+	desc  *prometheus.Desc
+	query func() ([]PoolStat, error)
+}
+
+// RegisterPool 注册口令池指标，每次抓取时调用 query 统计可用口令
+func RegisterPool(query func() ([]PoolStat, error)) error {
+	return prometheus.Register(&poolCollector{
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "pool_commands"),
+			"可用口令数，按来源和已展示次数统计",
+			[]string{"source", "display_count"}, nil,
+		),
+		query: query,
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.query()
+	if err != nil {
+		log.Printf("统计口令池失败: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	for _, stat := range stats {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(stat.Count),
+			stat.Source, strconv.Itoa(stat.DisplayCount))
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+	"yuanbao/repositories"
+)
+
+// LimiterStore 限流状态存储
+// fresh 返回 key 没有状态时的初始状态，同一 key 的 Take 必须串行执行
+type LimiterStore interface {
+	Take(key string, now time.Time, fresh func(now time.Time) bucket) (Decision, error)
+}
+
+// IPRecord IP访问记录
+type IPRecord struct {
+	bucket bucket
+	mu     sync.Mutex
+}
+
+// memoryStore 进程内存储，重启后清零，多实例之间不共享
+type memoryStore struct {
+	records sync.Map // map[string]*IPRecord
+}
+
+// NewMemoryStore 创建内存限流存储，ctx 取消时停止清理协程
+func NewMemoryStore(ctx context.Context) LimiterStore {
+	store := &memoryStore{}
+
+	// 启动清理协程，每分钟清理过期记录
+	go store.cleanup(ctx)
+
+	return store
+}
+
+// Take 在内存中消耗一次配额
+func (s *memoryStore) Take(key string, now time.Time, fresh func(now time.Time) bucket) (Decision, error) {
+	// 获取或创建记录
+	value, ok := s.records.Load(key)
+	if !ok {
+		value, _ = s.records.LoadOrStore(key, &IPRecord{bucket: fresh(now)})
+	}
+
+	record := value.(*IPRecord)
+	record.mu.Lock()
+	defer record.mu.Unlock()
+
+	return record.bucket.take(now), nil
+}
+
+// cleanup 定期清理已恢复初始状态的记录
+func (s *memoryStore) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		var now time.Time
+		select {
+		case <-ctx.Done():
+			return
+		case now = <-ticker.C:
+		}
+
+		s.records.Range(func(key, value interface{}) bool {
+			record := value.(*IPRecord)
+			record.mu.Lock()
+			if !now.Before(record.bucket.idleAt()) {
+				s.records.Delete(key)
+			}
+			record.mu.Unlock()
+			return true
+		})
+	}
+}
+
+// dbStore 数据库存储，多个实例共享配额，重启后保留
+// 过期状态由定时任务 rate_limit_cleanup 清理
+type dbStore struct {
+	store repositories.RateLimitStore
+}
+
+// NewDBStore 创建数据库限流存储
+func NewDBStore(store repositories.RateLimitStore) LimiterStore {
+	return &dbStore{store: store}
+}
+
+// Take 在数据库事务中读取状态、消耗配额并写回
+func (s *dbStore) Take(key string, now time.Time, fresh func(now time.Time) bucket) (Decision, error) {
+	var decision Decision
+	err := s.store.UpdateRateLimit(key, func(state string) (string, time.Time, error) {
+		b := fresh(now)
+		if state != "" {
+			// 状态损坏或算法变更时从初始状态重新开始
+			if err := json.Unmarshal([]byte(state), b); err != nil {
+				b = fresh(now)
+			}
+		}
+
+		decision = b.take(now)
+
+		data, err := json.Marshal(b)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		return string(data), b.idleAt(), nil
+	})
+	return decision, err
+}
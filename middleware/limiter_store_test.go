@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"yuanbao/config"
+	"yuanbao/repositories"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openSharedStore 打开同一个 SQLite 文件，模拟共享数据库的一个实例
+func openSharedStore(t *testing.T, path string) repositories.CommandStore {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(path+"?_txlock=immediate&_busy_timeout=5000"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := repositories.StoreOptions{MaxDisplayCount: 3}
+	if err := repositories.AutoMigrate(db, opts); err != nil {
+		t.Fatal(err)
+	}
+	return repositories.NewSQLiteStore(db, opts)
+}
+
+// TestDBStoreSharedAcrossInstances 两个实例共享同一张限流表，并发请求合计不超过 limit
+func TestDBStoreSharedAcrossInstances(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	instances := []repositories.CommandStore{openSharedStore(t, path), openSharedStore(t, path)}
+
+	algorithms := []string{config.AlgorithmFixedWindow, config.AlgorithmSlidingLog, config.AlgorithmTokenBucket}
+	for _, algorithm := range algorithms {
+		rule := config.LimitRule{Limit: 5, Window: time.Minute, Algorithm: algorithm}
+		limiters := []*RateLimiter{
+			NewRateLimiter(rule, NewDBStore(instances[0])),
+			NewRateLimiter(rule, NewDBStore(instances[1])),
+		}
+		key := fmt.Sprintf("get:h:client:%s", algorithm)
+
+		var allowed int32
+		var wg sync.WaitGroup
+		for i := 0; i < 30; i++ {
+			wg.Add(1)
+			go func(rl *RateLimiter) {
+				defer wg.Done()
+				d, err := rl.Allow(key)
+				if err != nil {
+					t.Errorf("%s: Allow: %v", algorithm, err)
+					return
+				}
+				if d.Allowed {
+					atomic.AddInt32(&allowed, 1)
+				}
+			}(limiters[i%len(limiters)])
+		}
+		wg.Wait()
+
+		if allowed != int32(rule.Limit) {
+			t.Errorf("%s: %d requests allowed across two instances, want %d", algorithm, allowed, rule.Limit)
+		}
+
+		// 配额用完后任何一个实例都拒绝
+		for i, rl := range limiters {
+			if d, err := rl.Allow(key); err != nil || d.Allowed {
+				t.Errorf("%s: instance %d allowed a request after the shared quota ran out (%v)", algorithm, i, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+	"yuanbao/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 记录每个路由的请求耗时
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// 使用路由模板而不是实际路径，避免 token 等参数导致指标爆炸
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(c.Request.Method, route, strconv.Itoa(c.Writer.Status()), time.Since(start))
+	}
+}
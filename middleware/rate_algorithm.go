@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"math"
+	"time"
+	"yuanbao/config"
+)
+
+// Decision 一次限流判断的结果
+type Decision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int           // 本次判断后剩余可用次数
+	Reset      time.Time     // 配额完全恢复的时间
+	RetryAfter time.Duration // 被拒绝时至少需要等待的时间
+}
+
+// bucket 单个客户端的限流状态，调用方负责加锁
+// 导出字段为需要持久化的状态（JSON），其余字段来自限流规则
+type bucket interface {
+	// take 尝试消耗一次配额
+	take(now time.Time) Decision
+	// idleAt 返回状态恢复为初始值的时间，之后可以删除
+	idleAt() time.Time
+}
+
+// newBucketFunc 根据规则返回创建限流状态的函数，未知算法按固定窗口处理
+func newBucketFunc(rule config.LimitRule) func(now time.Time) bucket {
+	switch rule.Algorithm {
+	case config.AlgorithmSlidingLog:
+		return func(now time.Time) bucket {
+			return &slidingLog{limit: rule.Limit, window: rule.Window}
+		}
+	case config.AlgorithmTokenBucket:
+		burst := rule.Burst
+		if burst <= 0 {
+			burst = rule.Limit
+		}
+		rate := float64(rule.Limit) / rule.Window.Seconds() // 每秒补充的令牌数
+		return func(now time.Time) bucket {
+			return &tokenBucket{burst: burst, rate: rate, Tokens: float64(burst), Last: now}
+		}
+	default:
+		return func(now time.Time) bucket {
+			return &fixedWindow{limit: rule.Limit, window: rule.Window, ResetTime: now.Add(rule.Window)}
+		}
+	}
+}
+
+// fixedWindow 固定窗口：窗口内最多 limit 次，窗口结束时计数清零
+type fixedWindow struct {
+	limit     int
+	window    time.Duration
+	Count     int       `json:"count"`
+	ResetTime time.Time `json:"reset_time"`
+}
+
+func (b *fixedWindow) take(now time.Time) Decision {
+	// 如果已过期，重置计数
+	if now.After(b.ResetTime) {
+		b.Count = 0
+		b.ResetTime = now.Add(b.window)
+	}
+
+	d := Decision{Limit: b.limit, Reset: b.ResetTime}
+	if b.Count >= b.limit {
+		d.RetryAfter = b.ResetTime.Sub(now)
+		return d
+	}
+
+	b.Count++
+	d.Allowed = true
+	d.Remaining = b.limit - b.Count
+	return d
+}
+
+func (b *fixedWindow) idleAt() time.Time {
+	return b.ResetTime
+}
+
+// slidingLog 滑动日志：记录最近 window 内每次请求的时间，任意 window 内最多 limit 次
+type slidingLog struct {
+	limit  int
+	window time.Duration
+	Log    []time.Time `json:"log"` // 按时间升序
+}
+
+func (b *slidingLog) take(now time.Time) Decision {
+	// 丢弃窗口外的记录
+	cutoff := now.Add(-b.window)
+	i := 0
+	for i < len(b.Log) && !b.Log[i].After(cutoff) {
+		i++
+	}
+	b.Log = b.Log[i:]
+
+	d := Decision{Limit: b.limit}
+	if len(b.Log) >= b.limit {
+		// 最早一条记录滑出窗口后才有空位
+		d.RetryAfter = b.Log[0].Add(b.window).Sub(now)
+		d.Reset = b.idleAt()
+		return d
+	}
+
+	b.Log = append(b.Log, now)
+	d.Allowed = true
+	d.Remaining = b.limit - len(b.Log)
+	d.Reset = b.idleAt()
+	return d
+}
+
+func (b *slidingLog) idleAt() time.Time {
+	if len(b.Log) == 0 {
+		return time.Time{}
+	}
+	return b.Log[len(b.Log)-1].Add(b.window)
+}
+
+// tokenBucket 令牌桶：每秒补充 rate 个令牌，最多积累 burst 个，每次请求消耗一个
+type tokenBucket struct {
+	burst  int
+	rate   float64
+	Tokens float64   `json:"tokens"`
+	Last   time.Time `json:"last"`
+}
+
+func (b *tokenBucket) take(now time.Time) Decision {
+	b.refill(now)
+
+	d := Decision{Limit: b.burst}
+	if b.Tokens < 1 {
+		d.RetryAfter = b.wait(1 - b.Tokens)
+		d.Reset = b.idleAt()
+		return d
+	}
+
+	b.Tokens--
+	d.Allowed = true
+	d.Remaining = int(math.Floor(b.Tokens))
+	d.Reset = b.idleAt()
+	return d
+}
+
+func (b *tokenBucket) idleAt() time.Time {
+	return b.Last.Add(b.wait(float64(b.burst) - b.Tokens))
+}
+
+// refill 按经过的时间补充令牌
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.Last).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(float64(b.burst), b.Tokens+elapsed*b.rate)
+		b.Last = now
+	}
+}
+
+// wait 补充 n 个令牌所需的时间
+func (b *tokenBucket) wait(n float64) time.Duration {
+	return time.Duration(math.Ceil(n / b.rate * float64(time.Second)))
+}
@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+	"yuanbao/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// rateStep 一次请求：相对起始时间的时刻和期望的判断结果（Reset 同样相对起始时间）
+type rateStep struct {
+	at         time.Duration
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration
+	reset      time.Duration
+}
+
+// runSteps 用同一个限流状态依次执行 steps
+func runSteps(t *testing.T, rule config.LimitRule, steps []rateStep) {
+	t.Helper()
+	start := time.Date(2025, 1, 28, 12, 0, 0, 0, time.UTC)
+	b := newBucketFunc(rule)(start)
+
+	for i, s := range steps {
+		d := b.take(start.Add(s.at))
+		got := rateStep{
+			at:         s.at,
+			allowed:    d.Allowed,
+			remaining:  d.Remaining,
+			retryAfter: d.RetryAfter.Round(time.Millisecond),
+			reset:      d.Reset.Sub(start).Round(time.Millisecond),
+		}
+		if got != s {
+			t.Errorf("step %d at %v: got %+v, want %+v", i, s.at, got, s)
+		}
+	}
+}
+
+func TestFixedWindow(t *testing.T) {
+	rule := config.LimitRule{Limit: 3, Window: time.Minute, Algorithm: config.AlgorithmFixedWindow}
+	s := time.Second
+	// 窗口边界前后各用满一次配额，约2秒内放行了两倍的请求
+	runSteps(t, rule, []rateStep{
+		{59 * s, true, 2, 0, 60 * s},
+		{59 * s, true, 1, 0, 60 * s},
+		{59 * s, true, 0, 0, 60 * s},
+		{59 * s, false, 0, 1 * s, 60 * s},
+		{61 * s, true, 2, 0, 121 * s},
+		{61 * s, true, 1, 0, 121 * s},
+		{61 * s, true, 0, 0, 121 * s},
+		{62 * s, false, 0, 59 * s, 121 * s},
+	})
+}
+
+func TestSlidingLog(t *testing.T) {
+	rule := config.LimitRule{Limit: 3, Window: time.Minute, Algorithm: config.AlgorithmSlidingLog}
+	s := time.Second
+	// 同样的请求序列，窗口边界之后仍然被拒绝，直到最早的记录滑出窗口
+	runSteps(t, rule, []rateStep{
+		{59 * s, true, 2, 0, 119 * s},
+		{59 * s, true, 1, 0, 119 * s},
+		{59 * s, true, 0, 0, 119 * s},
+		{61 * s, false, 0, 58 * s, 119 * s},
+		{118 * s, false, 0, 1 * s, 119 * s},
+		{119 * s, true, 2, 0, 179 * s},
+	})
+}
+
+func TestSlidingLogPartialExpiry(t *testing.T) {
+	rule := config.LimitRule{Limit: 2, Window: time.Minute, Algorithm: config.AlgorithmSlidingLog}
+	s := time.Second
+	runSteps(t, rule, []rateStep{
+		{0, true, 1, 0, 60 * s},
+		{30 * s, true, 0, 0, 90 * s},
+		{45 * s, false, 0, 15 * s, 90 * s},
+		// 第一条滑出窗口，空出一个位置
+		{61 * s, true, 0, 0, 121 * s},
+		{62 * s, false, 0, 28 * s, 121 * s},
+	})
+}
+
+func TestTokenBucket(t *testing.T) {
+	// 每秒补充2个令牌，最多积累4个
+	rule := config.LimitRule{Limit: 4, Window: 2 * time.Second, Algorithm: config.AlgorithmTokenBucket}
+	ms := time.Millisecond
+	runSteps(t, rule, []rateStep{
+		{0, true, 3, 0, 500 * ms},
+		{0, true, 2, 0, 1000 * ms},
+		{0, true, 1, 0, 1500 * ms},
+		{0, true, 0, 0, 2000 * ms},
+		{0, false, 0, 500 * ms, 2000 * ms},
+		// 补充了半个令牌
+		{250 * ms, false, 0, 250 * ms, 2000 * ms},
+		{500 * ms, true, 0, 0, 2500 * ms},
+		// 长时间空闲后最多积累 burst 个令牌
+		{10 * time.Second, true, 3, 0, 10500 * ms},
+		{10 * time.Second, true, 2, 0, 11000 * ms},
+		{10 * time.Second, true, 1, 0, 11500 * ms},
+		{10 * time.Second, true, 0, 0, 12000 * ms},
+		{10 * time.Second, false, 0, 500 * ms, 12000 * ms},
+	})
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	// burst 小于 limit 时突发请求受 burst 限制，补充速度仍为 limit/window
+	rule := config.LimitRule{Limit: 4, Window: 2 * time.Second, Burst: 2, Algorithm: config.AlgorithmTokenBucket}
+	ms := time.Millisecond
+	runSteps(t, rule, []rateStep{
+		{0, true, 1, 0, 500 * ms},
+		{0, true, 0, 0, 1000 * ms},
+		{0, false, 0, 500 * ms, 1000 * ms},
+		{500 * ms, true, 0, 0, 1500 * ms},
+	})
+
+	b := newBucketFunc(rule)(time.Now())
+	if d := b.take(time.Now()); d.Limit != 2 {
+		t.Errorf("Limit = %d, want burst 2", d.Limit)
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	setRateLimitHeaders(c, Decision{
+		Limit:      3,
+		Remaining:  0,
+		Reset:      time.Now().Add(58*time.Second + 100*time.Millisecond),
+		RetryAfter: 1500 * time.Millisecond,
+	})
+
+	want := map[string]string{
+		"X-RateLimit-Limit":     "3",
+		"X-RateLimit-Remaining": "0",
+		"X-RateLimit-Reset":     "59", // 向上取整
+		"Retry-After":           "2",
+	}
+	for name, value := range want {
+		if got := w.Header().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
@@ -1,107 +1,69 @@
 package middleware
 
 import (
+	"fmt"
+	"log"
 	"net/http"
-	"sync"
+	"strconv"
 	"time"
+	"yuanbao/config"
+	"yuanbao/identity"
+	"yuanbao/metrics"
 
 	"github.com/gin-gonic/gin"
 )
 
-// IPRecord IP访问记录
-type IPRecord struct {
-	Count      int
-	ResetTime  time.Time
-	mu         sync.Mutex
-}
-
-// RateLimiter 频率限制器
+// RateLimiter 频率限制器，算法由 config.LimitRule.Algorithm 决定，状态保存在 LimiterStore
 type RateLimiter struct {
-	records sync.Map // map[string]*IPRecord
-	limit   int
-	window  time.Duration
+	store     LimiterStore
+	limit     int
+	window    time.Duration
+	newBucket func(now time.Time) bucket
 }
 
 // NewRateLimiter 创建频率限制器
-func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
-	limiter := &RateLimiter{
-		limit:  limit,
-		window: window,
-	}
-
-	// 启动清理协程，每分钟清理过期记录
-	go limiter.cleanup()
-
-	return limiter
-}
-
-// Allow 检查是否允许访问
-func (rl *RateLimiter) Allow(ip string) bool {
-	now := time.Now()
-
-	// 获取或创建记录
-	value, _ := rl.records.LoadOrStore(ip, &IPRecord{
-		Count:     0,
-		ResetTime: now.Add(rl.window),
-	})
-
-	record := value.(*IPRecord)
-	record.mu.Lock()
-	defer record.mu.Unlock()
-
-	// 如果已过期，重置计数
-	if now.After(record.ResetTime) {
-		record.Count = 0
-		record.ResetTime = now.Add(rl.window)
+func NewRateLimiter(rule config.LimitRule, store LimiterStore) *RateLimiter {
+	return &RateLimiter{
+		store:     store,
+		limit:     rule.Limit,
+		window:    rule.Window,
+		newBucket: newBucketFunc(rule),
 	}
-
-	// 检查是否超限
-	if record.Count >= rl.limit {
-		return false
-	}
-
-	// 增加计数
-	record.Count++
-	return true
 }
 
-// cleanup 定期清理过期记录
-func (rl *RateLimiter) cleanup() {
-	ticker := time.NewTicker(1 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		now := time.Now()
-		rl.records.Range(func(key, value interface{}) bool {
-			record := value.(*IPRecord)
-			record.mu.Lock()
-			// 如果记录已过期超过5分钟，删除
-			if now.After(record.ResetTime.Add(5 * time.Minute)) {
-				rl.records.Delete(key)
-			}
-			record.mu.Unlock()
-			return true
-		})
-	}
+// Allow 检查是否允许访问，并返回剩余配额
+func (rl *RateLimiter) Allow(key string) (Decision, error) {
+	return rl.store.Take(key, time.Now(), rl.newBucket)
 }
 
 // Middleware 创建限流中间件
 func (rl *RateLimiter) Middleware(action string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		ip := c.ClientIP()
+		// 不同动作分别计数
+		decision, err := rl.Allow(action + ":" + identity.FromContext(c))
+		if err != nil {
+			// 存储不可用时放行，避免限流故障影响正常使用
+			log.Printf("限流状态读取失败（%s）: %v", action, err)
+			c.Next()
+			return
+		}
+		setRateLimitHeaders(c, decision)
+
+		if !decision.Allowed {
+			metrics.ObserveRateLimited(action)
 
-		if !rl.Allow(ip) {
 			var message string
 			if action == "upload" {
-				message = "同一IP每分钟最多上传5次，请稍后再试"
+				message = fmt.Sprintf("同一IP每%s最多上传%d次，请稍后再试", formatWindow(rl.window), rl.limit)
 			} else if action == "get" {
-				message = "您的获取次数已达上限（每分钟20次），请稍后再试"
+				message = fmt.Sprintf("您的获取次数已达上限（每%s%d次），请稍后再试", formatWindow(rl.window), rl.limit)
 			} else {
 				message = "操作过于频繁，请稍后再试"
 			}
 
 			c.JSON(http.StatusTooManyRequests, gin.H{
 				"success": false,
+				"code":    "rate_limited",
 				"message": message,
 			})
 			c.Abort()
@@ -111,3 +73,37 @@ func (rl *RateLimiter) Middleware(action string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// setRateLimitHeaders 写入限流响应头，Reset 和 Retry-After 为距今的秒数（向上取整）
+func setRateLimitHeaders(c *gin.Context, d Decision) {
+	c.Header("X-RateLimit-Limit", strconv.Itoa(d.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(time.Until(d.Reset))))
+	if !d.Allowed {
+		c.Header("Retry-After", strconv.Itoa(ceilSeconds(d.RetryAfter)))
+	}
+}
+
+// ceilSeconds 将时长转换为向上取整的秒数，最小为0
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// formatWindow 将时间窗口格式化为提示文案，如 1m -> 分钟，10m -> 10分钟
+func formatWindow(window time.Duration) string {
+	switch {
+	case window == time.Minute:
+		return "分钟"
+	case window == time.Hour:
+		return "小时"
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%d小时", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%d分钟", window/time.Minute)
+	default:
+		return window.String()
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// 领取状态
+const (
+	ClaimStatusLeased    = "leased"    // 已租用，等待确认
+	ClaimStatusConfirmed = "confirmed" // 已确认领取，计入展示次数
+	ClaimStatusReleased  = "released"  // 用户主动归还
+	ClaimStatusExpired   = "expired"   // 超时未确认，由回收任务归还
+)
+
+// Claim 口令领取记录（租约）
+// 获取口令时先占用一个名额，用户确认后才计入 DisplayCount，超时或归还则释放名额
+type Claim struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	Token      string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"token"`
+	CommandID  uint       `gorm:"not null;index" json:"command_id"`
+	ClientIP   string     `gorm:"type:varchar(50);index" json:"client_ip,omitempty"`
+	Status     string     `gorm:"type:varchar(20);not null;default:'leased';index" json:"status"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"` // 确认/归还/过期时间
+	CreatedAt  time.Time  `gorm:"not null" json:"created_at"`
+}
+
+// TableName 指定表名
+func (Claim) TableName() string {
+	return "claims"
+}
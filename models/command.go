@@ -4,14 +4,107 @@ import (
 	"time"
 )
 
+// 口令状态
+const (
+	CommandStatusPending     = "pending"     // 已写入，尚未开放领取
+	CommandStatusActive      = "active"      // 有剩余名额，参与随机选择
+	CommandStatusLeased      = "leased"      // 剩余名额都已被租用，等待确认或归还
+	CommandStatusExhausted   = "exhausted"   // 展示次数已达上限
+	CommandStatusQuarantined = "quarantined" // 举报达到阈值，等待管理员审核
+	CommandStatusExpired     = "expired"     // 已过期
+	CommandStatusRetired     = "retired"     // 因反馈失败下架
+)
+
+// commandTransitions 允许的状态迁移，key 为当前状态
+// active/leased/exhausted 之间随名额变化自动切换；retired 和 expired 只能进入 expired 或保持不变
+var commandTransitions = map[string][]string{
+	CommandStatusPending:     {CommandStatusActive, CommandStatusExpired},
+	CommandStatusActive:      {CommandStatusLeased, CommandStatusExhausted, CommandStatusQuarantined, CommandStatusExpired, CommandStatusRetired},
+	CommandStatusLeased:      {CommandStatusActive, CommandStatusExhausted, CommandStatusQuarantined, CommandStatusExpired, CommandStatusRetired},
+	CommandStatusExhausted:   {CommandStatusActive, CommandStatusLeased, CommandStatusQuarantined, CommandStatusExpired, CommandStatusRetired},
+	CommandStatusQuarantined: {CommandStatusActive, CommandStatusLeased, CommandStatusExhausted, CommandStatusExpired},
+	CommandStatusRetired:     {CommandStatusExpired},
+}
+
+// IsValidCommandStatus 判断状态是否合法
+func IsValidCommandStatus(status string) bool {
+	switch status {
+	case CommandStatusPending, CommandStatusActive, CommandStatusLeased, CommandStatusExhausted,
+		CommandStatusQuarantined, CommandStatusExpired, CommandStatusRetired:
+		return true
+	}
+	return false
+}
+
+// HoldsFingerprint 判断该状态的口令是否占用去重指纹
+// 已过期、已下架的口令不再参与去重，进入这两个状态时清空指纹，同一口令可以重新上传
+func HoldsFingerprint(status string) bool {
+	return status != CommandStatusExpired && status != CommandStatusRetired
+}
+
+// CanTransition 判断口令能否从 from 状态迁移到 to 状态
+func CanTransition(from, to string) bool {
+	for _, s := range commandTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
 // Command 口令实体
 type Command struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	Content      string    `gorm:"type:varchar(500);not null;uniqueIndex" json:"content"` // 添加唯一索引防重复
-	Source       string    `gorm:"type:varchar(20);not null;default:'user';index" json:"source"` // 来源：crawler(爬虫) 或 user(用户上传)
-	UploaderIP   string    `gorm:"type:varchar(50);index" json:"uploader_ip,omitempty"` // 上传者IP（仅用户上传时有值）
-	DisplayCount int       `gorm:"not null;default:0" json:"display_count"`
-	CreatedAt    time.Time `gorm:"not null;index" json:"created_at"` // 添加索引用于定时清理
+	ID           uint   `gorm:"primaryKey;index:idx_commands_status_id,priority:2;index:idx_commands_status_source_id,priority:3" json:"id"`
+	Content      string `gorm:"type:varchar(500);not null" json:"content"`                                                                   // 提交的原文
+	Code         string `gorm:"type:varchar(500);index" json:"code"`                                                                         // 从原文中解析出的口令本体，用于校验和展示
+	Fingerprint  string `gorm:"type:varchar(500);index:idx_commands_fingerprint,unique" json:"-"`                                            // 口令本体规范化后的指纹，添加唯一索引防重复；过期、下架后清空，见 HoldsFingerprint
+	AmountCents  int    `gorm:"not null;default:0" json:"amount_cents,omitempty"`                                                            // 文案中的红包金额（分），0 表示未提及
+	Campaign     string `gorm:"type:varchar(100)" json:"campaign,omitempty"`                                                                 // 文案中的活动名称
+	Source       string `gorm:"type:varchar(20);not null;default:'user';index;index:idx_commands_status_source_id,priority:2" json:"source"` // 来源：crawler(爬虫) 或 user(用户上传)
+	UploaderIP   string `gorm:"type:varchar(50);index" json:"uploader_ip,omitempty"`                                                         // 上传者IP（仅用户上传时有值）
+	Origin       string `gorm:"type:varchar(100);index" json:"origin,omitempty"`                                                             // 采集来源名称（仅爬虫口令），如 tieba_thread
+	OriginURL    string `gorm:"type:varchar(500)" json:"origin_url,omitempty"`                                                               // 采集出处地址（帖子、文件或订阅条目）
+	DisplayCount int    `gorm:"not null;default:0" json:"display_count"`
+	DisplayLimit int    `gorm:"not null;default:0" json:"display_limit"` // 最多展示次数（默认取 command.max_display_count）
+	LeasedCount  int    `gorm:"not null;default:0" json:"leased_count"`  // 已租用但未确认的名额
+	SuccessCount int    `gorm:"not null;default:0" json:"success_count"` // 反馈领取成功次数
+	FailureCount int    `gorm:"not null;default:0" json:"failure_count"` // 反馈失败次数（已领完/过期/格式错误）
+	ReportCount  int    `gorm:"not null;default:0" json:"report_count"`  // 不同IP的无效举报次数
+
+	Status          string     `gorm:"type:varchar(20);not null;default:'active';index:idx_commands_status_id,priority:1;index:idx_commands_status_source_id,priority:1" json:"status"` // 生命周期状态，见 CommandStatus* 常量；与 ID（及来源）组成联合索引，选择口令时只扫描 active 口令
+	StatusChangedAt *time.Time `json:"status_changed_at,omitempty"`                                                                                                                     // 最近一次状态变化的时间
+	ActivatedAt     *time.Time `json:"activated_at,omitempty"`                                                                                                                          // 开放领取的时间
+	ExhaustedAt     *time.Time `json:"exhausted_at,omitempty"`                                                                                                                          // 展示次数达到上限的时间
+	QuarantinedAt   *time.Time `gorm:"index" json:"quarantined_at,omitempty"`                                                                                                           // 举报达到阈值被隔离的时间
+	RetiredAt       *time.Time `gorm:"index" json:"retired_at,omitempty"`                                                                                                               // 因反馈失败下架的时间
+	ExpiredAt       *time.Time `json:"expired_at,omitempty"`                                                                                                                            // 被标记为过期的时间
+	ExpiresAt       *time.Time `gorm:"index" json:"expires_at,omitempty"`                                                                                                               // 口令（红包）失效时间，nil 表示不过期
+	CreatedAt       time.Time  `gorm:"not null;index" json:"created_at"`                                                                                                                // 添加索引用于定时清理
+}
+
+// SuccessRate 反馈成功率（拉普拉斯平滑，没有反馈时为0.5）
+func (c *Command) SuccessRate() float64 {
+	return float64(c.SuccessCount+1) / float64(c.SuccessCount+c.FailureCount+2)
+}
+
+// Payload 展示给用户的口令：解析出的口令本体，旧数据没有时使用原文
+func (c *Command) Payload() string {
+	if c.Code != "" {
+		return c.Code
+	}
+	return c.Content
+}
+
+// CapacityStatus 根据名额使用情况计算的状态：active、leased 或 exhausted
+func (c *Command) CapacityStatus() string {
+	switch {
+	case c.DisplayCount >= c.DisplayLimit:
+		return CommandStatusExhausted
+	case c.DisplayCount+c.LeasedCount >= c.DisplayLimit:
+		return CommandStatusLeased
+	default:
+		return CommandStatusActive
+	}
 }
 
 // TableName 指定表名
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// 爬虫执行状态
+const (
+	CrawlerRunRunning     = "running"     // 执行中
+	CrawlerRunSucceeded   = "success"     // 执行成功
+	CrawlerRunFailed      = "failed"      // 执行失败（可能已保存部分口令）
+	CrawlerRunInterrupted = "interrupted" // 服务关闭或重启导致中断
+)
+
+// CrawlerRun 采集来源的一次执行记录
+type CrawlerRun struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Source         string     `gorm:"type:varchar(100);not null;index" json:"source"` // 采集来源名称
+	Instance       string     `gorm:"type:varchar(100);index" json:"instance"`        // 执行的实例（server.instance_id）
+	Status         string     `gorm:"type:varchar(20);not null;index" json:"status"`
+	StartedAt      time.Time  `gorm:"not null;index" json:"started_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+	FetchedCount   int        `gorm:"not null;default:0" json:"fetched_count"`   // 采集到的口令数
+	SavedCount     int        `gorm:"not null;default:0" json:"saved_count"`     // 成功保存
+	DuplicateCount int        `gorm:"not null;default:0" json:"duplicate_count"` // 重复跳过
+	ErrorCount     int        `gorm:"not null;default:0" json:"error_count"`     // 保存失败
+	Error          string     `gorm:"type:text" json:"error,omitempty"`          // 采集失败原因
+	LogExcerpt     string     `gorm:"type:text" json:"log_excerpt,omitempty"`    // 保存失败等日志摘录
+}
+
+// TableName 指定表名
+func (CrawlerRun) TableName() string {
+	return "crawler_runs"
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// CommandDelivery 口令发放记录
+// 同一请求者对同一口令只会发放一次
+type CommandDelivery struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CommandID uint      `gorm:"not null;uniqueIndex:idx_delivery_command_requester" json:"command_id"`
+	Requester string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_delivery_command_requester;index" json:"requester"` // 请求者标识
+	Strategy  string    `gorm:"type:varchar(30);index" json:"strategy"`                                                      // 选择口令使用的策略
+	CreatedAt time.Time `gorm:"not null;index" json:"created_at"`
+}
+
+// TableName 指定表名
+func (CommandDelivery) TableName() string {
+	return "command_deliveries"
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// 领取结果
+const (
+	OutcomeSuccess        = "success"         // 成功领取红包
+	OutcomeAlreadyClaimed = "already_claimed" // 红包已被领完
+	OutcomeExpired        = "expired"         // 红包已过期
+	OutcomeMalformed      = "malformed"       // 口令格式错误，无法识别
+)
+
+// IsValidOutcome 判断是否为合法的领取结果
+func IsValidOutcome(outcome string) bool {
+	switch outcome {
+	case OutcomeSuccess, OutcomeAlreadyClaimed, OutcomeExpired, OutcomeMalformed:
+		return true
+	}
+	return false
+}
+
+// CommandFeedback 口令领取结果反馈
+// 每次领取（Claim）最多反馈一次
+type CommandFeedback struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CommandID  uint      `gorm:"not null;index" json:"command_id"`
+	ClaimID    uint      `gorm:"not null;uniqueIndex" json:"claim_id"`
+	Outcome    string    `gorm:"type:varchar(20);not null;index" json:"outcome"`
+	ReporterIP string    `gorm:"type:varchar(50)" json:"reporter_ip,omitempty"`
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+}
+
+// TableName 指定表名
+func (CommandFeedback) TableName() string {
+	return "command_feedback"
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitState 限流状态，多个实例共享同一份配额
+type RateLimitState struct {
+	Key       string    `gorm:"column:limit_key;primaryKey;type:varchar(200)" json:"key"` // 限流动作 + 客户端标识
+	State     string    `gorm:"type:text" json:"state"`                                   // 算法状态（JSON）
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`                         // 状态恢复初始值的时间，之后可删除
+}
+
+// TableName 指定表名
+func (RateLimitState) TableName() string {
+	return "rate_limit_states"
+}
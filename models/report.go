@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// CommandReport 口令无效举报记录
+// 同一IP对同一口令只能举报一次
+type CommandReport struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CommandID  uint      `gorm:"not null;uniqueIndex:idx_report_command_reporter" json:"command_id"`
+	ReporterIP string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_report_command_reporter" json:"reporter_ip"`
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+}
+
+// TableName 指定表名
+func (CommandReport) TableName() string {
+	return "command_reports"
+}
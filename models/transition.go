@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// CommandTransition 口令状态变化记录
+type CommandTransition struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CommandID  uint      `gorm:"not null;index" json:"command_id"`
+	FromStatus string    `gorm:"type:varchar(20);not null" json:"from_status"`
+	ToStatus   string    `gorm:"type:varchar(20);not null" json:"to_status"`
+	Reason     string    `gorm:"type:varchar(50)" json:"reason"` // 触发原因，如 lease、confirm、report
+	CreatedAt  time.Time `gorm:"not null" json:"created_at"`
+}
+
+// TableName 指定表名
+func (CommandTransition) TableName() string {
+	return "command_transitions"
+}
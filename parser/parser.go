@@ -0,0 +1,144 @@
+// Package parser 解析元宝口令红包的分享文本
+//
+// 用户和爬虫提交的往往是整段分享文案，例如：
+//
+//	【腾讯元宝】新春红包来啦！复制这段话 ¥Ab3dE9xQ¥ 打开腾讯元宝App即可领取8.88元现金红包 https://...
+//	元宝口令：新年快乐万事如意，活动：春节红包雨
+//
+// Parse 从中提取真正需要输入的口令（Code），以及文案中的红包金额和活动名称。
+// 解析前先做 Unicode NFKC 规范化（全角字母、数字、标点转为半角），再按以下顺序识别：
+//  1. 分隔符包裹的口令：¥…¥、￥…￥、€…€、$…$、₤…₤、「…」，引导语、链接等其余内容丢弃
+//  2. 带标签的口令：口令：…、暗号：…、红包码：…、兑换码：…，同上
+//  3. 以上都没有时，整段文本作为口令（纯文本口令本身可能包含“元宝”“红包”等字样，不做删减）
+//
+// Fingerprint 在口令本体的基础上去除零宽字符和表情、合并空白，相同指纹的口令视为重复。
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 识别到的格式
+const (
+	FormatDelimited = "delimited" // 分隔符包裹
+	FormatLabeled   = "labeled"   // 带标签
+	FormatPlain     = "plain"     // 纯文本
+)
+
+// Result 解析结果
+type Result struct {
+	Code        string // 口令本体，用于校验、去重和展示
+	AmountCents int    // 文案中的红包金额（分），0 表示未提及
+	Campaign    string // 文案中的活动名称
+	Format      string // 识别到的格式，见 Format* 常量
+	Fingerprint string // 去重指纹
+}
+
+var (
+	delimitedPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`[¥￥]\s*([^\s¥￥]{4,64}?)\s*[¥￥]`),
+		regexp.MustCompile(`€\s*([^\s€]{4,64}?)\s*€`),
+		regexp.MustCompile(`\$\s*([^\s$]{4,64}?)\s*\$`),
+		regexp.MustCompile(`₤\s*([^\s₤]{4,64}?)\s*₤`),
+		regexp.MustCompile(`「\s*([^「」]{2,64}?)\s*」`),
+	}
+	labeledPattern = regexp.MustCompile(`(?:口令|暗号|红包码|兑换码)\s*[:：]\s*([^\s，,。！!；;、]+)`)
+
+	amountPattern          = regexp.MustCompile(`(\d{1,5}(?:\.\d{1,2})?)\s*(?:元|块)(?:[^宝]|$)`) // 不匹配“元宝”中的“元”
+	campaignLabelPattern   = regexp.MustCompile(`活动(?:名称)?\s*[:：]\s*([^\s，,。！!；;、]+)`)
+	campaignBracketPattern = regexp.MustCompile(`【([^【】]{2,30})】`)
+)
+
+// appNames 方括号中出现的应用名称，不作为活动名称
+var appNames = map[string]bool{
+	"腾讯元宝": true,
+	"元宝":   true,
+	"元宝红包": true,
+}
+
+// Parse 解析分享文本
+func Parse(text string) Result {
+	result := parse(norm.NFKC.String(strings.TrimSpace(text)))
+	result.Fingerprint = Fingerprint(result.Code)
+	return result
+}
+
+// Fingerprint 计算口令本体的去重指纹：NFKC 规范化，去除零宽等格式字符和表情，连续空白合并为一个空格
+func Fingerprint(code string) string {
+	code = strings.Map(func(r rune) rune {
+		if unicode.In(r, unicode.Cf, unicode.So, unicode.Variation_Selector) {
+			return -1
+		}
+		return r
+	}, norm.NFKC.String(code))
+	return strings.Join(strings.Fields(code), " ")
+}
+
+// parse 依次按分隔符、标签、纯文本识别口令
+func parse(text string) Result {
+	result := Result{
+		AmountCents: parseAmount(text),
+		Campaign:    parseCampaign(text),
+	}
+
+	for _, pattern := range delimitedPatterns {
+		if match := pattern.FindStringSubmatch(text); match != nil {
+			if code := trimCode(match[1]); code != "" {
+				result.Code = code
+				result.Format = FormatDelimited
+				return result
+			}
+		}
+	}
+
+	if match := labeledPattern.FindStringSubmatch(text); match != nil {
+		if code := trimCode(match[1]); code != "" {
+			result.Code = code
+			result.Format = FormatLabeled
+			return result
+		}
+	}
+
+	result.Format = FormatPlain
+	result.Code = text
+	return result
+}
+
+// trimCode 去除口令首尾的空白和标点
+func trimCode(code string) string {
+	return strings.TrimFunc(code, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.Is(unicode.So, r)
+	})
+}
+
+// parseAmount 提取 “8.88元”、“5块” 形式的金额，返回分
+func parseAmount(text string) int {
+	match := amountPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+	yuan, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0
+	}
+	return int(yuan*100 + 0.5)
+}
+
+// parseCampaign 提取 “活动：xxx” 或方括号中的活动名称（忽略应用名称）
+func parseCampaign(text string) string {
+	if match := campaignLabelPattern.FindStringSubmatch(text); match != nil {
+		return match[1]
+	}
+	for _, match := range campaignBracketPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.TrimSpace(match[1])
+		if !appNames[name] {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package parser
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		code     string
+		amount   int
+		campaign string
+		format   string
+	}{
+		{
+			name:   "delimited share text",
+			text:   "【腾讯元宝】新春红包来啦！复制这段话 ¥Ab3dE9xQ¥ 打开腾讯元宝App即可领取8.88元现金红包 https://yb.tencent.com/s/x",
+			code:   "Ab3dE9xQ",
+			amount: 888,
+			format: FormatDelimited,
+		},
+		{
+			name:   "full-width delimiters and letters",
+			text:   "复制￥Ａｂ３ｄＥ９ｘＱ￥打开元宝",
+			code:   "Ab3dE9xQ",
+			format: FormatDelimited,
+		},
+		{
+			name:   "corner brackets",
+			text:   "口令「新年快乐万事如意」领5块",
+			code:   "新年快乐万事如意",
+			amount: 500,
+			format: FormatDelimited,
+		},
+		{
+			name:     "labeled with campaign",
+			text:     "元宝口令：新年快乐万事如意，活动：春节红包雨",
+			code:     "新年快乐万事如意",
+			campaign: "春节红包雨",
+			format:   FormatLabeled,
+		},
+		{
+			name:     "bracket campaign skips app name",
+			text:     "【腾讯元宝】【除夕红包】暗号：HappyNewYear2025",
+			code:     "HappyNewYear2025",
+			campaign: "除夕红包",
+			format:   FormatLabeled,
+		},
+		{
+			name:   "plain text containing 元宝 and 红包 is kept intact",
+			text:   "恭喜发财大吉大利元宝红包送给你",
+			code:   "恭喜发财大吉大利元宝红包送给你",
+			format: FormatPlain,
+		},
+		{
+			name:   "number before 元宝 is not an amount",
+			text:   "2025元宝新春快乐",
+			code:   "2025元宝新春快乐",
+			format: FormatPlain,
+		},
+		{
+			name:   "amount at end of text",
+			text:   "¥Zq81LmPw¥ 共10元",
+			code:   "Zq81LmPw",
+			amount: 1000,
+			format: FormatDelimited,
+		},
+		{
+			name:   "plain text is trimmed only",
+			text:   "  Hello World Code  ",
+			code:   "Hello World Code",
+			format: FormatPlain,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.text)
+			if got.Code != tt.code || got.AmountCents != tt.amount || got.Campaign != tt.campaign || got.Format != tt.format {
+				t.Errorf("Parse(%q) = {Code:%q AmountCents:%d Campaign:%q Format:%q}, want {Code:%q AmountCents:%d Campaign:%q Format:%q}",
+					tt.text, got.Code, got.AmountCents, got.Campaign, got.Format, tt.code, tt.amount, tt.campaign, tt.format)
+			}
+		})
+	}
+}
+
+func TestFingerprintMatchesVariants(t *testing.T) {
+	base := Parse("Hello World Code").Fingerprint
+	variants := []string{
+		"Hello  World\tCode",
+		"Ｈｅｌｌｏ　Ｗｏｒｌｄ　Ｃｏｄｅ",
+		"Hello World Code 🎉",
+		"Hello World Code​",
+		"Hello World Code ❤️",
+	}
+	for _, v := range variants {
+		if got := Parse(v).Fingerprint; got != base {
+			t.Errorf("Parse(%q).Fingerprint = %q, want %q", v, got, base)
+		}
+	}
+
+	if Parse("¥Ab3dE9xQ¥ 复制打开元宝").Fingerprint != Parse("口令：Ab3dE9xQ").Fingerprint {
+		t.Error("same code in different share text should have the same fingerprint")
+	}
+	if Parse("Hello World Code").Fingerprint == Parse("Hello World Cod3").Fingerprint {
+		t.Error("different codes should have different fingerprints")
+	}
+}
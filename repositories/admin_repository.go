@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"time"
+	"yuanbao/models"
+)
+
+// CommandFilter 管理后台口令查询条件，零值字段表示不过滤
+type CommandFilter struct {
+	Source          string     // 来源
+	Status          string     // 生命周期状态
+	UploaderIPs     []string   // 上传者标识，匹配任意一个
+	Keyword         string     // 内容关键字
+	MinDisplayCount *int       // 展示次数下限（含）
+	MaxDisplayCount *int       // 展示次数上限（含）
+	CreatedAfter    *time.Time // 创建时间晚于
+	CreatedBefore   *time.Time // 创建时间早于
+}
+
+// AdminStore 管理后台存储接口
+type AdminStore interface {
+	// ListCommands 按条件分页查询口令（最新的在前）
+	ListCommands(filter CommandFilter, offset, limit int) ([]models.Command, int64, error)
+	// CreateCommand 直接插入口令（pending 状态，由业务层激活），未设置展示上限时使用默认值
+	CreateCommand(command *models.Command) error
+	// UpdateCommandDisplay 修改口令的展示上限和已展示次数，nil 表示不修改
+	UpdateCommandDisplay(id uint, displayLimit, displayCount *int) error
+	// DeleteCommands 批量删除口令及其关联记录（状态变化、领取、反馈、举报、发放）
+	DeleteCommands(ids []uint) (int64, error)
+}
+
+// ListCommands 按条件分页查询口令
+func (s *gormStore) ListCommands(filter CommandFilter, offset, limit int) ([]models.Command, int64, error) {
+	var commands []models.Command
+	var total int64
+
+	query := s.db.Model(&models.Command{})
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if len(filter.UploaderIPs) > 0 {
+		query = query.Where("uploader_ip IN ?", filter.UploaderIPs)
+	}
+	if filter.Keyword != "" {
+		query = query.Where("content LIKE ?", "%"+filter.Keyword+"%")
+	}
+	if filter.MinDisplayCount != nil {
+		query = query.Where("display_count >= ?", *filter.MinDisplayCount)
+	}
+	if filter.MaxDisplayCount != nil {
+		query = query.Where("display_count <= ?", *filter.MaxDisplayCount)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at > ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&commands).Error
+	return commands, total, err
+}
+
+// CreateCommand 直接插入口令
+func (s *gormStore) CreateCommand(command *models.Command) error {
+	if command.DisplayLimit == 0 {
+		command.DisplayLimit = s.opts.MaxDisplayCount
+	}
+	command.Status = models.CommandStatusPending
+	return s.db.Create(command).Error
+}
+
+// UpdateCommandDisplay 修改口令的展示上限和已展示次数
+// 不根据影响行数判断口令是否存在（MySQL 在值未变化时返回0），由调用方先加锁查询
+func (s *gormStore) UpdateCommandDisplay(id uint, displayLimit, displayCount *int) error {
+	updates := map[string]interface{}{}
+	if displayLimit != nil {
+		updates["display_limit"] = *displayLimit
+	}
+	if displayCount != nil {
+		updates["display_count"] = *displayCount
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return s.db.Model(&models.Command{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// DeleteCommands 批量删除口令
+func (s *gormStore) DeleteCommands(ids []uint) (int64, error) {
+	if err := deleteCommandRelations(s.db, ids); err != nil {
+		return 0, err
+	}
+
+	result := s.db.Where("id IN ?", ids).Delete(&models.Command{})
+	return result.RowsAffected, result.Error
+}
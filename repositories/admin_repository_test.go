@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"fmt"
+	"testing"
+	"yuanbao/models"
+)
+
+// createCommandWithRelations 写入一条口令及每种关联记录各一条
+func createCommandWithRelations(t *testing.T, store CommandStore, status string, n int) uint {
+	t.Helper()
+	db := store.(*gormStore).db
+	code := fmt.Sprintf("RelatedCode%02d", n)
+	command := models.Command{Content: code, Code: code, Fingerprint: code, Source: "user", DisplayLimit: 3, Status: status}
+	if err := db.Create(&command).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	claim := models.Claim{Token: code, CommandID: command.ID, Status: models.ClaimStatusConfirmed}
+	if err := db.Create(&claim).Error; err != nil {
+		t.Fatal(err)
+	}
+	related := []interface{}{
+		&models.CommandTransition{CommandID: command.ID, FromStatus: models.CommandStatusActive, ToStatus: status},
+		&models.CommandFeedback{ClaimID: claim.ID, CommandID: command.ID, ReporterIP: "h:reporter", Outcome: models.OutcomeSuccess},
+		&models.CommandReport{CommandID: command.ID, ReporterIP: "h:reporter"},
+		&models.CommandDelivery{CommandID: command.ID, Requester: "h:requester"},
+	}
+	for _, r := range related {
+		if err := db.Create(r).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return command.ID
+}
+
+// assertNoRelations 口令及其关联记录都已删除
+func assertNoRelations(t *testing.T, store CommandStore, id uint) {
+	t.Helper()
+	db := store.(*gormStore).db
+	related := []interface{}{
+		&models.CommandTransition{},
+		&models.Claim{},
+		&models.CommandFeedback{},
+		&models.CommandReport{},
+		&models.CommandDelivery{},
+	}
+	for _, model := range related {
+		var count int64
+		if err := db.Model(model).Where("command_id = ?", id).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count > 0 {
+			t.Errorf("command #%d: %d %T rows left after delete", id, count, model)
+		}
+	}
+}
+
+func TestDeleteCommandsRemovesRelations(t *testing.T) {
+	store := NewSQLiteStore(openTestDB(t), StoreOptions{MaxDisplayCount: 3})
+
+	active := createCommandWithRelations(t, store, models.CommandStatusActive, 1)
+	quarantined := createCommandWithRelations(t, store, models.CommandStatusQuarantined, 2)
+
+	if deleted, err := store.DeleteCommands([]uint{active}); err != nil || deleted != 1 {
+		t.Fatalf("DeleteCommands = %d, %v", deleted, err)
+	}
+	assertNoRelations(t, store, active)
+
+	if err := store.PurgeCommand(quarantined); err != nil {
+		t.Fatalf("PurgeCommand: %v", err)
+	}
+	assertNoRelations(t, store, quarantined)
+}
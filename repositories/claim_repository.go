@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"yuanbao/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCommandUnavailable 口令在选中后已没有剩余名额（被并发请求占用或已下架）
+var ErrCommandUnavailable = errors.New("口令名额已被占用")
+
+// ClaimStore 口令领取（租约）存储接口
+type ClaimStore interface {
+	// CreateClaim 占用口令的一个名额并创建领取记录，没有剩余名额时返回 ErrCommandUnavailable
+	CreateClaim(claim *models.Claim) error
+	// FindClaimByTokenWithLock 根据令牌查询领取记录并加行锁（需在事务中调用）
+	FindClaimByTokenWithLock(token string) (*models.Claim, error)
+	// FinishClaim 结束领取：更新领取状态，归还或确认口令名额
+	FinishClaim(claim *models.Claim, status string) error
+	// FindExpiredClaims 查询已超时但仍处于租用状态的领取记录
+	FindExpiredClaims(now time.Time, limit int) ([]models.Claim, error)
+}
+
+// CreateClaim 创建领取记录
+// 名额通过带条件的原子 UPDATE 占用，不依赖行锁：即使两个请求选中了同一条口令，
+// 也只有条件仍然成立的那个能更新成功，展示上限不会被突破
+func (s *gormStore) CreateClaim(claim *models.Claim) error {
+	result := available(s.db.Model(&models.Command{})).
+		Where("id = ?", claim.CommandID).
+		Where("display_count + leased_count < display_limit").
+		Update("leased_count", gorm.Expr("leased_count + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCommandUnavailable
+	}
+
+	return s.db.Create(claim).Error
+}
+
+// FindClaimByTokenWithLock 根据令牌查询领取记录
+// 回收任务或并发的确认持有行锁时需要等待，不能像随机选择那样用 SKIP LOCKED 跳过，否则有效的令牌会被当作不存在
+func (s *gormStore) FindClaimByTokenWithLock(token string) (*models.Claim, error) {
+	query := s.db
+	if s.dialect.locking != nil {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var claim models.Claim
+	err := query.Where("token = ?", token).First(&claim).Error
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// FinishClaim 结束领取
+// confirmed：名额转为展示次数；released/expired：名额归还到口令池
+func (s *gormStore) FinishClaim(claim *models.Claim, status string) error {
+	now := time.Now()
+	claim.Status = status
+	claim.FinishedAt = &now
+	if err := s.db.Save(claim).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"leased_count": gorm.Expr("leased_count - 1"),
+	}
+	if status == models.ClaimStatusConfirmed {
+		updates["display_count"] = gorm.Expr("display_count + 1")
+	}
+
+	// 口令可能已被清理，此时没有需要归还的名额
+	return s.db.Model(&models.Command{}).
+		Where("id = ? AND leased_count > 0", claim.CommandID).
+		Updates(updates).Error
+}
+
+// FindExpiredClaims 查询超时未确认的领取记录
+func (s *gormStore) FindExpiredClaims(now time.Time, limit int) ([]models.Claim, error) {
+	var claims []models.Claim
+	err := s.db.
+		Where("status = ?", models.ClaimStatusLeased).
+		Where("expires_at < ?", now).
+		Order("expires_at").
+		Limit(limit).
+		Find(&claims).Error
+	return claims, err
+}
@@ -1,124 +1,239 @@
 package repositories
 
 import (
+	"math/rand"
 	"time"
-	"yuanbao/config"
 	"yuanbao/models"
+	"yuanbao/parser"
 
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 // SaveCommand 保存口令（用户上传）
-func SaveCommand(content string, uploaderIP string) (*models.Command, error) {
+func (s *gormStore) SaveCommand(content string, parsed parser.Result, uploaderIP string, expiresAt *time.Time) (*models.Command, error) {
 	command := &models.Command{
 		Content:      content,
+		Code:         parsed.Code,
+		AmountCents:  parsed.AmountCents,
+		Campaign:     parsed.Campaign,
+		Fingerprint:  parsed.Fingerprint,
 		Source:       "user",
 		UploaderIP:   uploaderIP,
 		DisplayCount: 0,
+		DisplayLimit: s.opts.MaxDisplayCount,
+		Status:       models.CommandStatusPending,
+		ExpiresAt:    expiresAt,
 	}
 
-	result := config.DB.Create(command)
+	result := s.db.Create(command)
 	return command, result.Error
 }
 
-// SaveCrawlerCommand 保存爬虫口令
-func SaveCrawlerCommand(content string) (*models.Command, error) {
+// SaveCrawlerCommand 保存爬虫口令，记录采集来源和出处
+func (s *gormStore) SaveCrawlerCommand(content string, parsed parser.Result, origin, originURL string, expiresAt *time.Time) (*models.Command, error) {
 	command := &models.Command{
 		Content:      content,
+		Code:         parsed.Code,
+		AmountCents:  parsed.AmountCents,
+		Campaign:     parsed.Campaign,
+		Fingerprint:  parsed.Fingerprint,
 		Source:       "crawler",
+		Origin:       origin,
+		OriginURL:    originURL,
 		DisplayCount: 0,
+		DisplayLimit: s.opts.MaxDisplayCount,
+		Status:       models.CommandStatusPending,
+		ExpiresAt:    expiresAt,
 	}
 
-	result := config.DB.Create(command)
+	result := s.db.Create(command)
 	return command, result.Error
 }
 
-// FindRandomCommandWithLock 使用悲观锁查询随机口令（优先用户上传，排除同IP）
-func FindRandomCommandWithLock(clientIP string) (*models.Command, error) {
+// FindCommandByCode 根据口令本体查询口令
+// 过期、下架后同一口令可以重新上传，存在多条时优先返回仍占用指纹的那条，其次是最新的
+func (s *gormStore) FindCommandByCode(code string) (*models.Command, error) {
 	var command models.Command
+	err := s.db.Where("code = ?", code).
+		Order("fingerprint IS NULL").
+		Order("id DESC").
+		Take(&command).Error
+	if err != nil {
+		return nil, err
+	}
+	return &command, nil
+}
 
-	// 使用悲观锁 (SELECT ... FOR UPDATE)
-	// 并发安全：同一时刻只有一个事务能锁定该行
-	// SQLite 使用 RANDOM()，MySQL 使用 RAND()
-
-	// 1. 优先查找用户上传的token（排除同IP）
-	err := config.DB.
-		Clauses(clause.Locking{Strength: "UPDATE"}).
-		Where("display_count < ?", 3).
-		Where("source = ?", "user").
-		Where("uploader_ip != ? OR uploader_ip IS NULL OR uploader_ip = ''", clientIP).
-		Order("RANDOM()").
-		First(&command).Error
-
-	// 如果找到用户上传的token，直接返回
-	if err == nil {
-		return &command, nil
+// FindCommandByFingerprint 根据去重指纹查询口令
+func (s *gormStore) FindCommandByFingerprint(fingerprint string) (*models.Command, error) {
+	var command models.Command
+	if err := s.db.Where("fingerprint = ?", fingerprint).First(&command).Error; err != nil {
+		return nil, err
 	}
+	return &command, nil
+}
+
+// sampleAttempts 抽到的候选都被其他事务锁定时，换一个随机位置重新抽样的次数
+const sampleAttempts = 3
 
-	// 2. 如果没有用户上传的token，查找爬虫token
-	if err == gorm.ErrRecordNotFound {
-		err = config.DB.
-			Clauses(clause.Locking{Strength: "UPDATE"}).
-			Where("display_count < ?", 3).
-			Where("source = ?", "crawler").
-			Order("RANDOM()").
-			First(&command).Error
-
-		if err == gorm.ErrRecordNotFound {
-			return nil, nil
+// FindRandomCommandWithLock 使用悲观锁按策略选择一条口令（排除同一客户端上传的和已发放给该客户端的）
+func (s *gormStore) FindRandomCommandWithLock(clientIDs []string, strategy SelectionStrategy) (*models.Command, error) {
+	tiers := strategy.Tiers()
+	if len(tiers) == 0 {
+		tiers = []string{""}
+	}
+
+	for _, tier := range tiers {
+		command, err := s.findInTier(clientIDs, strategy, tier)
+		if err != nil || command != nil {
+			return command, err
 		}
 	}
+	return nil, nil
+}
 
-	return &command, err
+// findInTier 在一层候选中选择并锁定一条口令
+// 抽样（SampleSize > 0，默认配置）时先从随机位置抽取一批候选ID，只对这批候选排序，策略排序因此只在候选内生效
+// SampleSize 为 0 时对全部可用口令按策略排序，候选只来自 (status, id) 索引中的 active 口令，耗时随可用口令数增长
+func (s *gormStore) findInTier(clientIDs []string, strategy SelectionStrategy, tier string) (*models.Command, error) {
+	attempts := 1
+	if s.opts.SampleSize > 0 {
+		attempts = sampleAttempts
+	}
+
+	for i := 0; i < attempts; i++ {
+		// 使用悲观锁 (SELECT ... FOR UPDATE SKIP LOCKED)
+		// 并发安全：同一时刻只有一个事务能锁定该行，其他事务跳过已锁定的行
+		// 随机函数和锁子句由方言决定，排序由选择策略决定
+		query := s.candidates(s.lockedQuery(), clientIDs, tier)
+		if s.opts.SampleSize > 0 {
+			ids, err := s.sampleCandidateIDs(clientIDs, tier)
+			if err != nil || len(ids) == 0 {
+				return nil, err
+			}
+			query = query.Where("id IN ?", ids)
+		}
+
+		var command models.Command
+		err := strategy.Order(query, s.dialect.random).First(&command).Error
+		if err == nil {
+			return &command, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+	}
+	return nil, nil
 }
 
-// UpdateCommand 更新口令
-func UpdateCommand(command *models.Command) error {
-	return config.DB.Save(command).Error
+// candidates 可以发放给请求者的口令：处于 active 状态（还有未租用的名额）、未到失效时间、不是本人上传、没有发放过
+// 到期但还未被过期任务处理的口令同样排除
+func (s *gormStore) candidates(db *gorm.DB, clientIDs []string, tier string) *gorm.DB {
+	query := available(db.Model(&models.Command{})).
+		Where("uploader_ip NOT IN ? OR uploader_ip IS NULL OR uploader_ip = ''", clientIDs).
+		Where(notDeliveredExpr, clientIDs)
+	if tier != "" {
+		query = query.Where(tier)
+	}
+	return query
 }
 
-// DeleteCommand 删除口令
-func DeleteCommand(id uint) error {
-	return config.DB.Delete(&models.Command{}, id).Error
+// sampleCandidateIDs 在 active 口令的 [最小ID, 最大ID] 中取随机位置，沿 (status, id) 索引向后取最多 SampleSize 个候选ID，不足时从头补齐
+// 只扫描 active 状态的口令，已领完、过期、下架的历史口令再多也不影响耗时
+// 抽样不是均匀的：active 口令的ID之间隔着其他状态的口令时，间隔之后的一批候选被抽中的概率与间隔长度成正比
+func (s *gormStore) sampleCandidateIDs(clientIDs []string, tier string) ([]uint, error) {
+	// MIN 和 MAX 分开查询，SQLite 只有单个聚合时才会直接读取索引两端
+	active := s.db.Model(&models.Command{}).Where("status = ?", models.CommandStatusActive)
+	var minID, maxID *uint
+	if err := active.Session(&gorm.Session{}).Select("MIN(id)").Scan(&minID).Error; err != nil || minID == nil {
+		return nil, err
+	}
+	if err := active.Session(&gorm.Session{}).Select("MAX(id)").Scan(&maxID).Error; err != nil || maxID == nil {
+		return nil, err
+	}
+
+	pivot := *minID + uint(rand.Int63n(int64(*maxID-*minID)+1))
+	size := s.opts.SampleSize
+
+	var ids []uint
+	err := s.candidates(s.db, clientIDs, tier).
+		Where("id >= ?", pivot).
+		Order("id").
+		Limit(size).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == size {
+		return ids, err
+	}
+
+	var wrapped []uint
+	err = s.candidates(s.db, clientIDs, tier).
+		Where("id < ?", pivot).
+		Order("id").
+		Limit(size-len(ids)).
+		Pluck("id", &wrapped).Error
+	return append(ids, wrapped...), err
 }
 
-// CountAvailableCommands 统计可用口令数量
-func CountAvailableCommands() (int64, error) {
-	var count int64
-	err := config.DB.Model(&models.Command{}).
-		Where("display_count < ?", 3).
-		Count(&count).Error
-	return count, err
+// available 限定为可以领取的口令：active 状态且未到失效时间
+func available(query *gorm.DB) *gorm.DB {
+	return query.
+		Where("status = ?", models.CommandStatusActive).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now())
 }
 
-// MarkCommandAsInvalid 标记口令为无效（直接删除）
-func MarkCommandAsInvalid(content string) error {
-	result := config.DB.Where("content = ?", content).Delete(&models.Command{})
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
+// UpdateCommand 更新口令
+func (s *gormStore) UpdateCommand(command *models.Command) error {
+	return s.db.Save(command).Error
+}
+
+// DeleteCommand 删除口令及其关联记录
+func (s *gormStore) DeleteCommand(id uint) error {
+	if err := deleteCommandRelations(s.db, []uint{id}); err != nil {
+		return err
 	}
-	return nil
+	return s.db.Delete(&models.Command{}, id).Error
 }
 
-// CleanOldCrawlerCommands 清理1小时前的爬虫口令
-func CleanOldCrawlerCommands() (int64, error) {
-	oneHourAgo := time.Now().Add(-1 * time.Hour)
+// commandRelations 通过 command_id 关联口令的记录，删除口令时一并删除
+var commandRelations = []interface{}{
+	&models.CommandTransition{},
+	&models.Claim{},
+	&models.CommandFeedback{},
+	&models.CommandReport{},
+	&models.CommandDelivery{},
+}
 
-	result := config.DB.
-		Where("source = ?", "crawler").
-		Where("created_at < ?", oneHourAgo).
-		Delete(&models.Command{})
+// deleteCommandRelations 删除口令的状态变化、领取、反馈、举报和发放记录，删除口令前调用
+func deleteCommandRelations(db *gorm.DB, ids []uint) error {
+	for _, model := range commandRelations {
+		if err := db.Where("command_id IN ?", ids).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	return result.RowsAffected, result.Error
+// CountAvailableCommands 统计可用口令数量
+func (s *gormStore) CountAvailableCommands() (int64, error) {
+	var count int64
+	err := available(s.db.Model(&models.Command{})).
+		Count(&count).Error
+	return count, err
 }
 
-// CleanAllCommands 清空所有口令（每天0点执行）
-func CleanAllCommands() (int64, error) {
-	result := config.DB.Delete(&models.Command{}, "1=1")
-	return result.RowsAffected, result.Error
+// PoolBucket 某个来源、某个已展示次数的可用口令数
+type PoolBucket struct {
+	Source       string
+	DisplayCount int
+	Count        int64
 }
 
+// CountAvailableByBucket 按来源和已展示次数统计可用口令
+func (s *gormStore) CountAvailableByBucket() ([]PoolBucket, error) {
+	var buckets []PoolBucket
+	err := available(s.db.Model(&models.Command{})).
+		Select("source, display_count, COUNT(*) AS count").
+		Group("source, display_count").
+		Scan(&buckets).Error
+	return buckets, err
+}
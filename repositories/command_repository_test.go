@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"yuanbao/config"
+	"yuanbao/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB 在临时目录中创建并迁移 SQLite 数据库
+func openTestDB(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	dsn := filepath.Join(tb.TempDir(), "test.db") + "?_txlock=immediate&_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := AutoMigrate(db, StoreOptions{MaxDisplayCount: 3}); err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+// seedCommands 写入 total 条口令，每 total/active 条中有一条为 active，其余为已领完或已过期
+func seedCommands(tb testing.TB, db *gorm.DB, total, active int) {
+	tb.Helper()
+	step := total / active
+	dead := []string{models.CommandStatusExhausted, models.CommandStatusExpired}
+
+	commands := make([]models.Command, 0, total)
+	for i := 0; i < total; i++ {
+		status := dead[i%len(dead)]
+		if i%step == 0 {
+			status = models.CommandStatusActive
+		}
+		code := fmt.Sprintf("BenchCode%07d", i)
+		commands = append(commands, models.Command{
+			Content:      code,
+			Code:         code,
+			Fingerprint:  code,
+			Source:       "user",
+			UploaderIP:   "h:uploader",
+			DisplayLimit: 3,
+			Status:       status,
+		})
+	}
+	if err := db.CreateInBatches(commands, 500).Error; err != nil {
+		tb.Fatal(err)
+	}
+}
+
+func TestSampleCandidatesOnlyActive(t *testing.T) {
+	db := openTestDB(t)
+	seedCommands(t, db, 2000, 20)
+	store := NewSQLiteStore(db, StoreOptions{MaxDisplayCount: 3, SampleSize: 5})
+	strategy, _ := LookupStrategy(config.StrategyUserFirst)
+
+	for i := 0; i < 200; i++ {
+		command, err := store.FindRandomCommandWithLock([]string{"h:client"}, strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if command == nil {
+			t.Fatal("expected a command, got none")
+		}
+		if command.Status != models.CommandStatusActive {
+			t.Fatalf("command #%d has status %s, want active", command.ID, command.Status)
+		}
+	}
+}
+
+// BenchmarkFindRandomCommand 随机选择的耗时
+// 不抽样时主要取决于 active 口令数，历史口令（已领完、过期）只因 active 口令分散在更多数据页上略有影响；
+// 抽样时与两者基本无关
+func BenchmarkFindRandomCommand(b *testing.B) {
+	datasets := []struct {
+		total, active int
+	}{
+		{1000, 1000},
+		{10000, 1000},
+		{100000, 1000},
+		{100000, 100000},
+	}
+
+	for _, ds := range datasets {
+		db := openTestDB(b)
+		seedCommands(b, db, ds.total, ds.active)
+
+		for _, sampleSize := range []int{0, 50} {
+			name := fmt.Sprintf("total=%d/active=%d/sample=%d", ds.total, ds.active, sampleSize)
+			b.Run(name, func(b *testing.B) {
+				store := NewSQLiteStore(db, StoreOptions{MaxDisplayCount: 3, SampleSize: sampleSize})
+				strategy, _ := LookupStrategy(config.StrategyUserFirst)
+				clientIDs := []string{"h:client"}
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					command, err := store.FindRandomCommandWithLock(clientIDs, strategy)
+					if err != nil || command == nil {
+						b.Fatalf("select: %v, %v", command, err)
+					}
+				}
+			})
+		}
+	}
+}
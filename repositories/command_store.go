@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"yuanbao/config"
+	"yuanbao/models"
+	"yuanbao/parser"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CommandStore 口令存储接口
+// 不同数据库的随机排序和行锁语法不同，由具体实现负责处理
+type CommandStore interface {
+	ClaimStore
+	FeedbackStore
+	ReportStore
+	AdminStore
+	CrawlerRunStore
+	RateLimitStore
+	RetentionStore
+	DeliveryStore
+	LifecycleStore
+
+	// SaveCommand 保存用户上传的口令（pending 状态，由业务层激活），parsed 为原文的解析结果，expiresAt 为 nil 表示不过期
+	SaveCommand(content string, parsed parser.Result, uploaderIP string, expiresAt *time.Time) (*models.Command, error)
+	// SaveCrawlerCommand 保存爬虫采集的口令，origin 为采集来源名称，originURL 为出处地址
+	SaveCrawlerCommand(content string, parsed parser.Result, origin, originURL string, expiresAt *time.Time) (*models.Command, error)
+	// FindCommandByCode 根据口令本体查询口令，存在多条时优先返回未过期、未下架的
+	FindCommandByCode(code string) (*models.Command, error)
+	// FindCommandByFingerprint 根据去重指纹查询口令
+	FindCommandByFingerprint(fingerprint string) (*models.Command, error)
+	// FindRandomCommandWithLock 按选择策略查询一条可用口令并加行锁（需在事务中调用）
+	// clientIDs 为请求者在所有密钥下的标识，排除其上传的和已经发放给他的口令
+	FindRandomCommandWithLock(clientIDs []string, strategy SelectionStrategy) (*models.Command, error)
+	// UpdateCommand 更新口令
+	UpdateCommand(command *models.Command) error
+	// DeleteCommand 删除口令及其关联记录
+	DeleteCommand(id uint) error
+	// CountAvailableCommands 统计可用口令数量
+	CountAvailableCommands() (int64, error)
+	// CountAvailableByBucket 按来源和已展示次数统计可用口令
+	CountAvailableByBucket() ([]PoolBucket, error)
+	// Transaction 在事务中执行 fn，fn 收到的 store 绑定到该事务
+	Transaction(fn func(store CommandStore) error) error
+}
+
+// dialect 数据库方言差异
+type dialect struct {
+	name    string
+	random  string          // 随机排序表达式
+	locking *clause.Locking // 行锁子句，nil 表示不支持
+}
+
+var (
+	// SQLite 没有行级锁，写事务本身是串行的，FOR UPDATE 会被忽略
+	sqliteDialect = dialect{
+		name:   config.DriverSQLite,
+		random: "RANDOM()",
+	}
+	// PostgreSQL 使用 SKIP LOCKED 跳过其他事务已锁定的行，避免排队等待
+	postgresDialect = dialect{
+		name:    config.DriverPostgres,
+		random:  "RANDOM()",
+		locking: &clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"},
+	}
+	// MySQL 8.0+ 支持 SKIP LOCKED，随机函数为 RAND()
+	mysqlDialect = dialect{
+		name:    config.DriverMySQL,
+		random:  "RAND()",
+		locking: &clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"},
+	}
+)
+
+// StoreOptions 存储层参数
+type StoreOptions struct {
+	MaxDisplayCount int                    // 新口令的默认展示上限
+	DefaultTTL      time.Duration          // 旧数据补齐过期时间时使用的有效期（用户上传、管理员添加），0 表示不过期
+	CrawlerTTL      time.Duration          // 旧数据补齐过期时间时使用的有效期（爬虫口令）
+	SampleSize      int                    // 随机选择时抽取的候选数，0 表示在全部可用口令中排序
+	HashIdentity    func(ip string) string // 迁移时将旧数据中的原始 IP 转换为散列后的标识，为 nil 时不处理
+}
+
+// gormStore 基于 GORM 的 CommandStore 实现
+type gormStore struct {
+	db      *gorm.DB
+	dialect dialect
+	opts    StoreOptions
+}
+
+// NewSQLiteStore 创建 SQLite 口令存储
+func NewSQLiteStore(db *gorm.DB, opts StoreOptions) CommandStore {
+	return &gormStore{db: db, dialect: sqliteDialect, opts: opts}
+}
+
+// NewPostgresStore 创建 PostgreSQL 口令存储
+func NewPostgresStore(db *gorm.DB, opts StoreOptions) CommandStore {
+	return &gormStore{db: db, dialect: postgresDialect, opts: opts}
+}
+
+// NewMySQLStore 创建 MySQL 口令存储
+func NewMySQLStore(db *gorm.DB, opts StoreOptions) CommandStore {
+	return &gormStore{db: db, dialect: mysqlDialect, opts: opts}
+}
+
+// NewCommandStore 根据驱动名创建对应的口令存储
+func NewCommandStore(db *gorm.DB, driver string, opts StoreOptions) (CommandStore, error) {
+	switch driver {
+	case config.DriverSQLite:
+		return NewSQLiteStore(db, opts), nil
+	case config.DriverPostgres:
+		return NewPostgresStore(db, opts), nil
+	case config.DriverMySQL:
+		return NewMySQLStore(db, opts), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
+}
+
+// busyRetries SQLite 写锁冲突时事务的重试次数
+const busyRetries = 3
+
+// Transaction 在事务中执行 fn
+// SQLite 等待写锁超时（database is locked）时整个事务重试，fn 可能被执行多次
+func (s *gormStore) Transaction(fn func(store CommandStore) error) error {
+	var err error
+	for attempt := 0; attempt <= busyRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 20 * time.Millisecond)
+		}
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			return fn(&gormStore{db: tx, dialect: s.dialect, opts: s.opts})
+		})
+		if !isBusyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// isBusyError 判断是否为 SQLite 写锁冲突
+func isBusyError(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "database is locked") || strings.Contains(err.Error(), "SQLITE_BUSY"))
+}
+
+// lockedQuery 返回带方言行锁的查询（PostgreSQL/MySQL 为 SKIP LOCKED），仅用于随机选择候选口令
+func (s *gormStore) lockedQuery() *gorm.DB {
+	if s.dialect.locking == nil {
+		return s.db
+	}
+	return s.db.Clauses(*s.dialect.locking)
+}
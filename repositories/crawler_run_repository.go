@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"yuanbao/models"
+
+	"gorm.io/gorm"
+)
+
+// CrawlerRunStore 爬虫执行记录存储接口
+type CrawlerRunStore interface {
+	// CreateCrawlerRun 记录一次执行的开始
+	CreateCrawlerRun(run *models.CrawlerRun) error
+	// FinishCrawlerRun 保存执行结果
+	FinishCrawlerRun(run *models.CrawlerRun) error
+	// InterruptCrawlerRuns 将实例 instance 仍处于执行中的记录标记为中断（服务重启后调用）
+	InterruptCrawlerRuns(instance string) (int64, error)
+	// ListCrawlerRuns 按来源和状态分页查询执行记录，参数为空时不过滤
+	ListCrawlerRuns(source, status string, offset, limit int) ([]models.CrawlerRun, int64, error)
+	// CrawlerSourceStats 统计某个来源的最近执行情况
+	CrawlerSourceStats(source string) (*CrawlerSourceStats, error)
+}
+
+// CrawlerSourceStats 采集来源的最近执行情况
+type CrawlerSourceStats struct {
+	LastRun        *models.CrawlerRun // 最近一次执行
+	LastSuccess    *models.CrawlerRun // 最近一次成功执行
+	LastProductive *models.CrawlerRun // 最近一次保存了新口令的执行
+	EmptyRuns      int64              // 最近一次保存新口令之后的已结束执行次数
+}
+
+// CreateCrawlerRun 记录一次执行的开始
+func (s *gormStore) CreateCrawlerRun(run *models.CrawlerRun) error {
+	return s.db.Create(run).Error
+}
+
+// FinishCrawlerRun 保存执行结果
+func (s *gormStore) FinishCrawlerRun(run *models.CrawlerRun) error {
+	return s.db.Save(run).Error
+}
+
+// InterruptCrawlerRuns 将实例仍处于执行中的记录标记为中断
+// 共享数据库的其他实例的记录可能仍在执行，不做处理；升级前的记录没有实例标识，一并标记
+func (s *gormStore) InterruptCrawlerRuns(instance string) (int64, error) {
+	result := s.db.Model(&models.CrawlerRun{}).
+		Where("status = ?", models.CrawlerRunRunning).
+		Where("instance = ? OR instance IS NULL OR instance = ''", instance).
+		Updates(map[string]interface{}{
+			"status":      models.CrawlerRunInterrupted,
+			"finished_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// ListCrawlerRuns 分页查询执行记录，最新的在前
+func (s *gormStore) ListCrawlerRuns(source, status string, offset, limit int) ([]models.CrawlerRun, int64, error) {
+	query := s.db.Model(&models.CrawlerRun{})
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var runs []models.CrawlerRun
+	err := query.Order("started_at DESC").Order("id DESC").
+		Offset(offset).Limit(limit).
+		Find(&runs).Error
+	return runs, total, err
+}
+
+// CrawlerSourceStats 统计某个来源的最近执行情况
+func (s *gormStore) CrawlerSourceStats(source string) (*CrawlerSourceStats, error) {
+	stats := &CrawlerSourceStats{}
+	var err error
+
+	if stats.LastRun, err = s.latestCrawlerRun(source, ""); err != nil {
+		return nil, err
+	}
+	if stats.LastSuccess, err = s.latestCrawlerRun(source, "status = ?", models.CrawlerRunSucceeded); err != nil {
+		return nil, err
+	}
+	if stats.LastProductive, err = s.latestCrawlerRun(source, "saved_count > 0"); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Model(&models.CrawlerRun{}).
+		Where("source = ? AND status != ?", source, models.CrawlerRunRunning)
+	if stats.LastProductive != nil {
+		query = query.Where("id > ?", stats.LastProductive.ID)
+	}
+	if err := query.Count(&stats.EmptyRuns).Error; err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// latestCrawlerRun 查询满足条件的最近一次执行，没有记录时返回 nil
+func (s *gormStore) latestCrawlerRun(source, cond string, args ...interface{}) (*models.CrawlerRun, error) {
+	query := s.db.Where("source = ?", source)
+	if cond != "" {
+		query = query.Where(cond, args...)
+	}
+
+	var run models.CrawlerRun
+	err := query.Order("id DESC").First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
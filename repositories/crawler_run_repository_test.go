@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+	"yuanbao/models"
+)
+
+func TestInterruptCrawlerRunsOnlyOwnInstance(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db, StoreOptions{MaxDisplayCount: 3})
+
+	runs := []struct {
+		instance string
+		status   string
+		want     string
+	}{
+		{"replica-a", models.CrawlerRunRunning, models.CrawlerRunInterrupted},
+		{"replica-b", models.CrawlerRunRunning, models.CrawlerRunRunning},
+		{"", models.CrawlerRunRunning, models.CrawlerRunInterrupted}, // 升级前没有实例标识
+		{"replica-a", models.CrawlerRunSucceeded, models.CrawlerRunSucceeded},
+	}
+	ids := make([]uint, len(runs))
+	for i, r := range runs {
+		run := models.CrawlerRun{Source: "feed", Instance: r.instance, Status: r.status, StartedAt: time.Now()}
+		if err := store.CreateCrawlerRun(&run); err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = run.ID
+	}
+
+	count, err := store.InterruptCrawlerRuns("replica-a")
+	if err != nil || count != 2 {
+		t.Fatalf("InterruptCrawlerRuns = %d, %v", count, err)
+	}
+	for i, r := range runs {
+		var run models.CrawlerRun
+		if err := db.First(&run, ids[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+		if run.Status != r.want {
+			t.Errorf("run of %q with status %s: got %s, want %s", r.instance, r.status, run.Status, r.want)
+		}
+	}
+}
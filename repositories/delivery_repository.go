@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"yuanbao/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// notDeliveredExpr 口令没有发放给任一请求者标识（参数为标识列表）
+const notDeliveredExpr = "NOT EXISTS (SELECT 1 FROM command_deliveries" +
+	" WHERE command_deliveries.command_id = commands.id AND command_deliveries.requester IN ?)"
+
+// DeliveryStore 口令发放记录存储接口
+type DeliveryStore interface {
+	// RecordDelivery 记录口令已按 strategy 策略发放给请求者，重复记录会被忽略
+	RecordDelivery(commandID uint, requester, strategy string) error
+}
+
+// RecordDelivery 记录口令发放
+func (s *gormStore) RecordDelivery(commandID uint, requester, strategy string) error {
+	delivery := &models.CommandDelivery{
+		CommandID: commandID,
+		Requester: requester,
+		Strategy:  strategy,
+	}
+	return s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(delivery).Error
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"yuanbao/models"
+
+	"gorm.io/gorm"
+)
+
+// successRateExpr 反馈成功率（拉普拉斯平滑），与 models.Command.SuccessRate 保持一致
+const successRateExpr = "(success_count + 1.0) / (success_count + failure_count + 2.0)"
+
+// FeedbackStore 领取结果反馈存储接口
+type FeedbackStore interface {
+	// FindFeedbackByClaim 查询某次领取的反馈，不存在时返回 nil
+	FindFeedbackByClaim(claimID uint) (*models.CommandFeedback, error)
+	// CreateFeedback 保存反馈并累加口令的成功/失败次数
+	CreateFeedback(feedback *models.CommandFeedback, failure bool) error
+	// FindCommandByID 根据ID查询口令
+	FindCommandByID(id uint) (*models.Command, error)
+}
+
+// FindFeedbackByClaim 查询某次领取的反馈
+func (s *gormStore) FindFeedbackByClaim(claimID uint) (*models.CommandFeedback, error) {
+	var feedback models.CommandFeedback
+	err := s.db.Where("claim_id = ?", claimID).First(&feedback).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &feedback, nil
+}
+
+// CreateFeedback 保存反馈并累加计数
+func (s *gormStore) CreateFeedback(feedback *models.CommandFeedback, failure bool) error {
+	if err := s.db.Create(feedback).Error; err != nil {
+		return err
+	}
+
+	column := "success_count"
+	if failure {
+		column = "failure_count"
+	}
+	return s.db.Model(&models.Command{}).
+		Where("id = ?", feedback.CommandID).
+		Update(column, gorm.Expr(column+" + 1")).Error
+}
+
+// FindCommandByID 根据ID查询口令
+func (s *gormStore) FindCommandByID(id uint) (*models.Command, error) {
+	var command models.Command
+	if err := s.db.First(&command, id).Error; err != nil {
+		return nil, err
+	}
+	return &command, nil
+}
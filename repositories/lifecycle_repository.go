@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+	"yuanbao/models"
+
+	"gorm.io/gorm/clause"
+)
+
+// ErrStatusChanged 口令状态在读取后已被其他事务修改
+var ErrStatusChanged = errors.New("口令状态已变化")
+
+// statusTimestamps 进入某个状态时同时记录的时间字段
+var statusTimestamps = map[string]string{
+	models.CommandStatusActive:      "activated_at",
+	models.CommandStatusExhausted:   "exhausted_at",
+	models.CommandStatusQuarantined: "quarantined_at",
+	models.CommandStatusRetired:     "retired_at",
+	models.CommandStatusExpired:     "expired_at",
+}
+
+// LifecycleStore 口令生命周期存储接口
+// 只负责持久化状态变化，迁移是否合法由业务层判断
+type LifecycleStore interface {
+	// FindCommandByIDWithLock 根据ID查询口令并加行锁（需在事务中调用）
+	FindCommandByIDWithLock(id uint) (*models.Command, error)
+	// UpdateCommandStatus 口令仍处于 from 状态时改为 to，记录时间和变化原因，状态已变化时返回 ErrStatusChanged
+	// 进入过期、下架状态时同时清空去重指纹
+	UpdateCommandStatus(command *models.Command, to, reason string) error
+	// ListCommandTransitions 查询口令的状态变化记录（按时间先后）
+	ListCommandTransitions(commandID uint) ([]models.CommandTransition, error)
+	// FindCommandsToExpire 查询失效时间早于 now、尚未标记为过期的口令
+	FindCommandsToExpire(now time.Time, limit int) ([]models.Command, error)
+}
+
+// FindCommandByIDWithLock 根据ID查询口令并加行锁
+// 这里需要等待而不是跳过其他事务持有的锁，不使用方言的 SKIP LOCKED
+func (s *gormStore) FindCommandByIDWithLock(id uint) (*models.Command, error) {
+	query := s.db
+	if s.dialect.locking != nil {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var command models.Command
+	if err := query.First(&command, id).Error; err != nil {
+		return nil, err
+	}
+	return &command, nil
+}
+
+// UpdateCommandStatus 更新口令状态并记录变化
+func (s *gormStore) UpdateCommandStatus(command *models.Command, to, reason string) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":            to,
+		"status_changed_at": now,
+	}
+	if column, ok := statusTimestamps[to]; ok {
+		updates[column] = now
+	}
+	if !models.HoldsFingerprint(to) {
+		updates["fingerprint"] = nil
+	}
+
+	// 以读取时的状态为条件，避免覆盖并发修改
+	result := s.db.Model(&models.Command{}).
+		Where("id = ? AND status = ?", command.ID, command.Status).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStatusChanged
+	}
+
+	err := s.db.Create(&models.CommandTransition{
+		CommandID:  command.ID,
+		FromStatus: command.Status,
+		ToStatus:   to,
+		Reason:     reason,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	command.Status = to
+	command.StatusChangedAt = &now
+	if !models.HoldsFingerprint(to) {
+		command.Fingerprint = ""
+	}
+	return nil
+}
+
+// ListCommandTransitions 查询口令的状态变化记录
+func (s *gormStore) ListCommandTransitions(commandID uint) ([]models.CommandTransition, error) {
+	var transitions []models.CommandTransition
+	err := s.db.
+		Where("command_id = ?", commandID).
+		Order("id").
+		Find(&transitions).Error
+	return transitions, err
+}
+
+// FindCommandsToExpire 查询到期的口令（最早到期的在前）
+func (s *gormStore) FindCommandsToExpire(now time.Time, limit int) ([]models.Command, error) {
+	var commands []models.Command
+	err := s.db.
+		Where("expires_at <= ?", now).
+		Where("status <> ?", models.CommandStatusExpired).
+		Order("expires_at").
+		Limit(limit).
+		Find(&commands).Error
+	return commands, err
+}
@@ -0,0 +1,312 @@
+package repositories
+
+import (
+	"fmt"
+	"log"
+
+	"yuanbao/models"
+	"yuanbao/parser"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// AutoMigrate 迁移数据库表结构，并补齐旧数据的新增字段
+func AutoMigrate(db *gorm.DB, opts StoreOptions) error {
+	// 状态字段是新增的，需要在迁移前判断，迁移后按旧字段推算
+	hasTable := db.Migrator().HasTable(&models.Command{})
+	hasStatus := hasTable && db.Migrator().HasColumn(&models.Command{}, "status")
+	hasExpiresAt := hasTable && db.Migrator().HasColumn(&models.Command{}, "expires_at")
+	hasCode := hasTable && db.Migrator().HasColumn(&models.Command{}, "code")
+	hasFingerprint := hasTable && db.Migrator().HasColumn(&models.Command{}, "fingerprint")
+
+	// 去重改为按指纹判断，原文上的唯一索引不再需要（同一口令的不同文案由指纹拦截）
+	// 状态索引改为 (status, id) 联合索引
+	for _, index := range []string{"idx_commands_content", "idx_commands_status"} {
+		if hasTable && db.Migrator().HasIndex(&models.Command{}, index) {
+			if err := db.Migrator().DropIndex(&models.Command{}, index); err != nil {
+				return err
+			}
+		}
+	}
+	if hasTable && !hasFingerprint {
+		if err := addFingerprintColumn(db); err != nil {
+			return err
+		}
+	}
+
+	err := db.AutoMigrate(
+		&models.Command{},
+		&models.Claim{},
+		&models.CommandFeedback{},
+		&models.CommandReport{},
+		&models.CrawlerRun{},
+		&models.RateLimitState{},
+		&models.CommandDelivery{},
+		&models.CommandTransition{},
+	)
+	if err != nil {
+		return err
+	}
+
+	// 旧数据没有单独的展示上限，使用全局配置
+	err = db.Model(&models.Command{}).
+		Where("display_limit = 0").
+		Update("display_limit", opts.MaxDisplayCount).Error
+	if err != nil {
+		return err
+	}
+
+	if !hasStatus {
+		if err := backfillStatus(db); err != nil {
+			return err
+		}
+	}
+	if hasTable && !hasExpiresAt {
+		if err := backfillExpiresAt(db, opts); err != nil {
+			return err
+		}
+	}
+	if hasTable && !hasCode {
+		if err := backfillCode(db); err != nil {
+			return err
+		}
+	}
+	if hasTable {
+		if err := deleteOrphanRelations(db); err != nil {
+			return err
+		}
+	}
+	if opts.HashIdentity != nil {
+		if err := hashIdentities(db, opts.HashIdentity); err != nil {
+			return err
+		}
+	}
+	if hasTable && !hasFingerprint {
+		return backfillFingerprint(db)
+	}
+
+	// 过期、下架的口令不再占用指纹（旧版本进入这两个状态时没有清空）
+	return db.Model(&models.Command{}).
+		Where("status IN ?", []string{models.CommandStatusExpired, models.CommandStatusRetired}).
+		Where("fingerprint IS NOT NULL").
+		Update("fingerprint", nil).Error
+}
+
+// backfillStatus 根据下架/隔离时间和名额使用情况推算旧数据的状态（新增列的默认值为 active）
+func backfillStatus(db *gorm.DB) error {
+	steps := []struct {
+		status string
+		where  string
+	}{
+		{models.CommandStatusRetired, "retired_at IS NOT NULL"},
+		{models.CommandStatusQuarantined, "quarantined_at IS NOT NULL"},
+		{models.CommandStatusExhausted, "display_count >= display_limit"},
+		{models.CommandStatusLeased, "display_count + leased_count >= display_limit"},
+	}
+
+	for _, step := range steps {
+		err := db.Model(&models.Command{}).
+			Where("status = ?", models.CommandStatusActive).
+			Where(step.where).
+			Update("status", step.status).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillExpiresAt 旧数据按创建时间加上有效期补齐失效时间，取代原来按固定时长清理的方式
+// 不同数据库的时间运算语法不同，在程序中逐批计算
+func backfillExpiresAt(db *gorm.DB, opts StoreOptions) error {
+	var commands []models.Command
+	return db.Select("id", "source", "created_at").
+		FindInBatches(&commands, 500, func(tx *gorm.DB, batch int) error {
+			for _, c := range commands {
+				ttl := opts.DefaultTTL
+				if c.Source == "crawler" {
+					ttl = opts.CrawlerTTL
+				}
+				if ttl <= 0 {
+					continue
+				}
+				err := db.Model(&models.Command{}).
+					Where("id = ?", c.ID).
+					Update("expires_at", c.CreatedAt.Add(ttl)).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// backfillCode 解析旧数据的原文，补齐口令本体、金额和活动名称
+func backfillCode(db *gorm.DB) error {
+	var commands []models.Command
+	return db.Select("id", "content").
+		FindInBatches(&commands, 500, func(tx *gorm.DB, batch int) error {
+			for _, c := range commands {
+				parsed := parser.Parse(c.Content)
+				err := db.Model(&models.Command{}).
+					Where("id = ?", c.ID).
+					Updates(map[string]interface{}{
+						"code":         parsed.Code,
+						"amount_cents": parsed.AmountCents,
+						"campaign":     parsed.Campaign,
+					}).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+// addFingerprintColumn 为已有的口令表添加指纹列和唯一索引
+// gorm 会把单列唯一索引当作列上的 UNIQUE 约束，而 SQLite 不支持添加带 UNIQUE 的列，所以分两步执行
+func addFingerprintColumn(db *gorm.DB) error {
+	if err := db.Exec("ALTER TABLE commands ADD COLUMN fingerprint VARCHAR(500)").Error; err != nil {
+		return err
+	}
+	return db.Exec("CREATE UNIQUE INDEX idx_commands_fingerprint ON commands (fingerprint)").Error
+}
+
+// backfillFingerprint 按新的规范化规则重新解析旧数据，补齐去重指纹
+// 过期、下架的口令不设置指纹；旧数据只按原文去重，可能存在指纹相同的口令：
+// 保留最早的一条，其余的不设置指纹，仍在展示的直接下架
+func backfillFingerprint(db *gorm.DB) error {
+	var commands []models.Command
+	seen := make(map[string]uint)
+	retired := 0
+
+	err := db.Select("id", "content", "status").
+		FindInBatches(&commands, 500, func(tx *gorm.DB, batch int) error {
+			for _, c := range commands {
+				parsed := parser.Parse(c.Content)
+				updates := map[string]interface{}{"code": parsed.Code}
+				duplicate := false
+				if models.HoldsFingerprint(c.Status) {
+					if _, duplicate = seen[parsed.Fingerprint]; !duplicate {
+						seen[parsed.Fingerprint] = c.ID
+						updates["fingerprint"] = parsed.Fingerprint
+					}
+				}
+				err := db.Model(&models.Command{}).Where("id = ?", c.ID).Updates(updates).Error
+				if err != nil {
+					return err
+				}
+
+				if duplicate && models.CanTransition(c.Status, models.CommandStatusRetired) {
+					if err := retireDuplicate(db, &c, seen[parsed.Fingerprint]); err != nil {
+						return err
+					}
+					retired++
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+
+	if retired > 0 {
+		log.Printf("迁移: %d 条口令与更早的口令重复，已下架", retired)
+	}
+	return nil
+}
+
+// retireDuplicate 下架与 originalID 重复的口令并记录状态变化
+func retireDuplicate(db *gorm.DB, command *models.Command, originalID uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		store := &gormStore{db: tx}
+		return store.UpdateCommandStatus(command, models.CommandStatusRetired, fmt.Sprintf("duplicate_of:%d", originalID))
+	})
+}
+
+// deleteOrphanRelations 删除口令已不存在的关联记录（旧版本删除口令时只删除了举报和状态变化记录）
+func deleteOrphanRelations(db *gorm.DB) error {
+	for _, model := range commandRelations {
+		result := db.Where("NOT EXISTS (SELECT 1 FROM commands WHERE commands.id = command_id)").Delete(model)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("迁移: 删除 %d 条口令已不存在的 %s 记录", result.RowsAffected, model.(schema.Tabler).TableName())
+		}
+	}
+	return nil
+}
+
+// hashedIdentityPattern 散列后的标识（identity 包生成，以 h: 开头），其余非空值是旧版本保存的原始 IP
+const hashedIdentityPattern = "h:%"
+
+// identityRow 迁移客户端标识时读取的记录
+type identityRow struct {
+	ID        uint
+	CommandID uint
+	Value     string
+}
+
+// hashIdentities 将旧数据中保存的原始 IP 替换为散列后的标识
+// 举报、发放记录按 (口令, 标识) 唯一，同一网段的多个 IPv6 地址散列后相同，只保留第一条
+func hashIdentities(db *gorm.DB, hash func(string) string) error {
+	columns := []struct {
+		model  schema.Tabler
+		column string
+		unique bool
+	}{
+		{&models.Command{}, "uploader_ip", false},
+		{&models.Claim{}, "client_ip", false},
+		{&models.CommandFeedback{}, "reporter_ip", false},
+		{&models.CommandReport{}, "reporter_ip", true},
+		{&models.CommandDelivery{}, "requester", true},
+	}
+
+	for _, col := range columns {
+		selected := "id, " + col.column + " AS value"
+		if col.unique {
+			selected += ", command_id"
+		}
+
+		hashed := 0
+		var rows []identityRow
+		err := db.Model(col.model).
+			Select(selected).
+			Where(col.column+" <> '' AND "+col.column+" NOT LIKE ?", hashedIdentityPattern).
+			FindInBatches(&rows, 500, func(tx *gorm.DB, batch int) error {
+				for _, row := range rows {
+					value := hash(row.Value)
+					if col.unique {
+						var count int64
+						err := db.Model(col.model).
+							Where("command_id = ? AND "+col.column+" = ?", row.CommandID, value).
+							Count(&count).Error
+						if err != nil {
+							return err
+						}
+						if count > 0 {
+							if err := db.Where("id = ?", row.ID).Delete(col.model).Error; err != nil {
+								return err
+							}
+							continue
+						}
+					}
+					err := db.Model(col.model).Where("id = ?", row.ID).Update(col.column, value).Error
+					if err != nil {
+						return err
+					}
+					hashed++
+				}
+				return nil
+			}).Error
+		if err != nil {
+			return err
+		}
+		if hashed > 0 {
+			log.Printf("迁移: %s.%s 中 %d 个原始 IP 已散列", col.model.TableName(), col.column, hashed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"strings"
+	"testing"
+	"yuanbao/models"
+)
+
+func TestMigrateHashesRawIdentities(t *testing.T) {
+	db := openTestDB(t)
+
+	command := models.Command{Content: "HashCode01", Code: "HashCode01", Fingerprint: "HashCode01", Source: "user", UploaderIP: "10.0.0.1", DisplayLimit: 3}
+	if err := db.Create(&command).Error; err != nil {
+		t.Fatal(err)
+	}
+	rows := []interface{}{
+		&models.Claim{Token: "t1", CommandID: command.ID, ClientIP: "10.0.0.2"},
+		&models.Claim{Token: "t2", CommandID: command.ID, ClientIP: "h:already"},
+		&models.CommandFeedback{CommandID: command.ID, ReporterIP: "10.0.0.3", Outcome: models.OutcomeSuccess},
+		// 同一网段的两个 IPv6 地址散列后相同，只保留一条
+		&models.CommandReport{CommandID: command.ID, ReporterIP: "2001:db8::1"},
+		&models.CommandReport{CommandID: command.ID, ReporterIP: "2001:db8::2"},
+		&models.CommandDelivery{CommandID: command.ID, Requester: "10.0.0.4"},
+	}
+	for _, r := range rows {
+		if err := db.Create(r).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 按 "::" 之前的部分散列，模拟 IPv6 按前缀聚合
+	hash := func(ip string) string { return "h:" + strings.SplitN(ip, "::", 2)[0] }
+	if err := AutoMigrate(db, StoreOptions{MaxDisplayCount: 3, HashIdentity: hash}); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		model  interface{}
+		column string
+		want   []string
+	}{
+		{&models.Command{}, "uploader_ip", []string{"h:10.0.0.1"}},
+		{&models.Claim{}, "client_ip", []string{"h:10.0.0.2", "h:already"}},
+		{&models.CommandFeedback{}, "reporter_ip", []string{"h:10.0.0.3"}},
+		{&models.CommandReport{}, "reporter_ip", []string{"h:2001:db8"}},
+		{&models.CommandDelivery{}, "requester", []string{"h:10.0.0.4"}},
+	}
+	for _, c := range checks {
+		var got []string
+		if err := db.Model(c.model).Order("id").Pluck(c.column, &got).Error; err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(got, ",") != strings.Join(c.want, ",") {
+			t.Errorf("%T.%s = %v, want %v", c.model, c.column, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"time"
+	"yuanbao/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RateLimitStore 共享限流状态数据访问
+type RateLimitStore interface {
+	// UpdateRateLimit 锁定 key 的限流状态，交给 fn 计算新状态及其过期时间后写回
+	// 状态不存在或已过期时 fn 收到空字符串
+	UpdateRateLimit(key string, fn func(state string) (string, time.Time, error)) error
+	// DeleteExpiredRateLimits 删除 before 之前过期的限流状态
+	DeleteExpiredRateLimits(before time.Time) (int64, error)
+}
+
+// UpdateRateLimit 在事务中读取、更新限流状态，同一 key 的并发请求串行执行
+func (s *gormStore) UpdateRateLimit(key string, fn func(state string) (string, time.Time, error)) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// 先保证行存在再加锁；SQLite 在这一步即取得写锁
+		placeholder := &models.RateLimitState{Key: key, ExpiresAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(placeholder).Error; err != nil {
+			return err
+		}
+
+		// 这里需要排队等待而不是跳过已锁定的行
+		query := tx
+		if s.dialect.locking != nil {
+			query = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		var row models.RateLimitState
+		if err := query.Where("limit_key = ?", key).First(&row).Error; err != nil {
+			return err
+		}
+
+		state := row.State
+		if row.ExpiresAt.Before(now) {
+			state = ""
+		}
+
+		next, expiresAt, err := fn(state)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.RateLimitState{}).
+			Where("limit_key = ?", key).
+			Updates(map[string]interface{}{
+				"state":      next,
+				"expires_at": expiresAt,
+			}).Error
+	})
+}
+
+// DeleteExpiredRateLimits 删除已过期的限流状态
+func (s *gormStore) DeleteExpiredRateLimits(before time.Time) (int64, error) {
+	result := s.db.Where("expires_at < ?", before).Delete(&models.RateLimitState{})
+	return result.RowsAffected, result.Error
+}
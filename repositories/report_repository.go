@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"yuanbao/models"
+
+	"gorm.io/gorm"
+)
+
+// ReportStore 无效举报与隔离区存储接口
+type ReportStore interface {
+	// HasReport 判断某IP是否已举报过该口令
+	HasReport(commandID uint, reporterIP string) (bool, error)
+	// CreateReport 保存举报并累加口令的举报次数
+	CreateReport(report *models.CommandReport) error
+	// ListQuarantinedCommands 分页查询隔离区口令
+	ListQuarantinedCommands(offset, limit int) ([]models.Command, int64, error)
+	// ClearReports 清空口令的举报次数和举报记录（恢复被隔离的口令时使用）
+	ClearReports(id uint) error
+	// PurgeCommand 彻底删除被隔离的口令及其关联记录
+	PurgeCommand(id uint) error
+}
+
+// HasReport 判断某IP是否已举报过该口令
+func (s *gormStore) HasReport(commandID uint, reporterIP string) (bool, error) {
+	var count int64
+	err := s.db.Model(&models.CommandReport{}).
+		Where("command_id = ? AND reporter_ip = ?", commandID, reporterIP).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateReport 保存举报并累加举报次数
+func (s *gormStore) CreateReport(report *models.CommandReport) error {
+	if err := s.db.Create(report).Error; err != nil {
+		return err
+	}
+
+	return s.db.Model(&models.Command{}).
+		Where("id = ?", report.CommandID).
+		Update("report_count", gorm.Expr("report_count + 1")).Error
+}
+
+// ListQuarantinedCommands 分页查询隔离区口令（最近隔离的在前）
+func (s *gormStore) ListQuarantinedCommands(offset, limit int) ([]models.Command, int64, error) {
+	var commands []models.Command
+	var total int64
+
+	query := s.db.Model(&models.Command{}).Where("status = ?", models.CommandStatusQuarantined)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.
+		Order("status_changed_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&commands).Error
+	return commands, total, err
+}
+
+// ClearReports 清空口令的举报记录
+func (s *gormStore) ClearReports(id uint) error {
+	err := s.db.Model(&models.Command{}).
+		Where("id = ?", id).
+		Update("report_count", 0).Error
+	if err != nil {
+		return err
+	}
+
+	return s.db.Where("command_id = ?", id).Delete(&models.CommandReport{}).Error
+}
+
+// PurgeCommand 彻底删除被隔离的口令
+func (s *gormStore) PurgeCommand(id uint) error {
+	result := s.db.
+		Where("id = ? AND status = ?", id, models.CommandStatusQuarantined).
+		Delete(&models.Command{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return deleteCommandRelations(s.db, []uint{id})
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"time"
+	"yuanbao/models"
+
+	"gorm.io/gorm"
+)
+
+// RetentionStore 客户端标识和历史口令的保留期清理
+type RetentionStore interface {
+	// PurgeIdentities 清空 before 之前创建的记录中的客户端标识，返回受影响的记录数
+	PurgeIdentities(before time.Time) (int64, error)
+	// PurgeCommands 删除最多 limit 个在 before 之前过期或下架的口令及其关联记录，返回删除的口令数
+	PurgeCommands(before time.Time, limit int) (int64, error)
+}
+
+// PurgeIdentities 清空口令上传者、领取者、反馈者标识，删除举报和发放记录
+// 举报和发放记录的标识参与唯一索引无法清空，直接删除；口令上的举报次数保留
+func (s *gormStore) PurgeIdentities(before time.Time) (int64, error) {
+	var total int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		updates := []struct {
+			model  interface{}
+			column string
+		}{
+			{&models.Command{}, "uploader_ip"},
+			{&models.Claim{}, "client_ip"},
+			{&models.CommandFeedback{}, "reporter_ip"},
+		}
+		for _, u := range updates {
+			result := tx.Model(u.model).
+				Where("created_at < ? AND "+u.column+" IS NOT NULL AND "+u.column+" <> ''", before).
+				Update(u.column, nil)
+			if result.Error != nil {
+				return result.Error
+			}
+			total += result.RowsAffected
+		}
+
+		for _, model := range []interface{}{&models.CommandReport{}, &models.CommandDelivery{}} {
+			result := tx.Where("created_at < ?", before).Delete(model)
+			if result.Error != nil {
+				return result.Error
+			}
+			total += result.RowsAffected
+		}
+		return nil
+	})
+	return total, err
+}
+
+// PurgeCommands 删除过期、下架超过保留期的口令，连同状态变化、领取、反馈、举报和发放记录
+// 按最近一次状态变化的时间判断，旧版本升级推算出的状态没有该时间，使用创建时间
+func (s *gormStore) PurgeCommands(before time.Time, limit int) (int64, error) {
+	var deleted int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		err := tx.Model(&models.Command{}).
+			Where("status IN ?", []string{models.CommandStatusExpired, models.CommandStatusRetired}).
+			Where("COALESCE(status_changed_at, created_at) < ?", before).
+			Order("id").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		if err := deleteCommandRelations(tx, ids); err != nil {
+			return err
+		}
+
+		result := tx.Where("id IN ?", ids).Delete(&models.Command{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"yuanbao/models"
+)
+
+func TestPurgeCommands(t *testing.T) {
+	db := openTestDB(t)
+	store := NewSQLiteStore(db, StoreOptions{MaxDisplayCount: 3})
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+
+	commands := []struct {
+		status    string
+		changedAt time.Time
+		purged    bool
+	}{
+		{models.CommandStatusExpired, old, true},
+		{models.CommandStatusRetired, old, true},
+		{models.CommandStatusExpired, now, false},
+		{models.CommandStatusExhausted, old, false},
+	}
+	ids := make([]uint, len(commands))
+	for i, c := range commands {
+		code := fmt.Sprintf("PurgeCode%02d", i)
+		command := models.Command{
+			Content:         code,
+			Code:            code,
+			Fingerprint:     code,
+			Source:          "user",
+			DisplayLimit:    3,
+			Status:          c.status,
+			StatusChangedAt: &c.changedAt,
+		}
+		if err := db.Create(&command).Error; err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = command.ID
+
+		related := []interface{}{
+			&models.CommandTransition{CommandID: command.ID, FromStatus: models.CommandStatusActive, ToStatus: c.status},
+			&models.Claim{Token: code, CommandID: command.ID, Status: models.ClaimStatusConfirmed, ExpiresAt: old},
+		}
+		for _, r := range related {
+			if err := db.Create(r).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	deleted, err := store.PurgeCommands(now.Add(-24*time.Hour), 1)
+	if err != nil || deleted != 1 {
+		t.Fatalf("PurgeCommands with limit 1 = %d, %v", deleted, err)
+	}
+	deleted, err = store.PurgeCommands(now.Add(-24*time.Hour), 10)
+	if err != nil || deleted != 1 {
+		t.Fatalf("PurgeCommands = %d, %v", deleted, err)
+	}
+
+	for i, c := range commands {
+		for _, model := range []interface{}{&models.Command{}, &models.CommandTransition{}, &models.Claim{}} {
+			column := "command_id"
+			if _, ok := model.(*models.Command); ok {
+				column = "id"
+			}
+			var count int64
+			if err := db.Model(model).Where(column+" = ?", ids[i]).Count(&count).Error; err != nil {
+				t.Fatal(err)
+			}
+			if exists := count > 0; exists == c.purged {
+				t.Errorf("%T of %s command changed at %s: exists = %v", model, c.status, c.changedAt.Format(time.RFC3339), exists)
+			}
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"yuanbao/config"
+
+	"gorm.io/gorm"
+)
+
+// SelectionStrategy 口令选择策略，决定候选口令的先后顺序
+// 候选范围（未用完、未下架、未隔离、非本人上传、未发放过）由 FindRandomCommandWithLock 统一过滤
+type SelectionStrategy interface {
+	// Name 策略名称，记录在发放记录上
+	Name() string
+	// Tiers 候选分层条件，前一层没有可用口令时才查找下一层，nil 表示不分层
+	Tiers() []string
+	// Order 为候选查询添加排序，random 为数据库方言的随机排序表达式
+	// 启用抽样（selection.sample_size > 0）时排序只在随机抽取的候选内生效
+	Order(query *gorm.DB, random string) *gorm.DB
+}
+
+// userFirstTiers 用户上传的口令用完后才发放爬虫和管理员添加的口令
+var userFirstTiers = []string{"source = 'user'", "source <> 'user'"}
+
+// orderStrategy 按固定排序表达式选择，最后按随机排序打散
+type orderStrategy struct {
+	name   string
+	tiers  []string
+	orders []string
+}
+
+func (s orderStrategy) Name() string {
+	return s.name
+}
+
+func (s orderStrategy) Tiers() []string {
+	return s.tiers
+}
+
+func (s orderStrategy) Order(query *gorm.DB, random string) *gorm.DB {
+	for _, order := range s.orders {
+		query = query.Order(order)
+	}
+	return query.Order(random)
+}
+
+// strategies 内置选择策略
+var strategies = map[string]SelectionStrategy{
+	// 优先用户上传，同一来源内优先反馈成功率高的，再随机
+	config.StrategyUserFirst: orderStrategy{config.StrategyUserFirst, userFirstTiers, []string{successRateExpr + " DESC"}},
+	// 最新入库的优先
+	config.StrategyFreshest: orderStrategy{config.StrategyFreshest, nil, []string{"created_at DESC"}},
+	// 展示（含租用中）次数最少的优先，让口令均匀曝光
+	config.StrategyLeastDisplayed: orderStrategy{config.StrategyLeastDisplayed, nil, []string{"display_count + leased_count ASC"}},
+	// 不区分来源，反馈成功率高的优先
+	config.StrategySuccessRate: orderStrategy{config.StrategySuccessRate, nil, []string{successRateExpr + " DESC"}},
+}
+
+// LookupStrategy 按名称查找选择策略
+func LookupStrategy(name string) (SelectionStrategy, bool) {
+	strategy, ok := strategies[name]
+	return strategy, ok
+}
@@ -0,0 +1,350 @@
+// Package scheduler 定时任务调度
+//
+// 每个任务使用 cron 表达式（支持 "@every 30m"、"@daily" 等描述符）定义执行时间，
+// 同一任务不会重叠执行：上一次未结束时，新的触发会被跳过。
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var (
+	// ErrJobNotFound 任务不存在
+	ErrJobNotFound = errors.New("定时任务不存在")
+	// ErrJobRunning 任务正在执行
+	ErrJobRunning = errors.New("定时任务正在执行")
+	// ErrStopped 调度器已停止
+	ErrStopped = errors.New("调度器已停止")
+)
+
+// Func 任务函数
+type Func func(ctx context.Context) error
+
+// Job 任务定义
+type Job struct {
+	Name    string        // 任务名称，唯一
+	Spec    string        // cron 表达式
+	Jitter  time.Duration // 定时触发时随机延迟 [0, Jitter)，手动执行不延迟
+	Enabled bool          // 是否按计划执行，禁用后仍可手动执行
+	Run     Func
+}
+
+// Status 任务状态
+type Status struct {
+	Name         string     `json:"name"`
+	Spec         string     `json:"spec"`
+	Jitter       string     `json:"jitter"`
+	Enabled      bool       `json:"enabled"`
+	Running      bool       `json:"running"`
+	NextRun      *time.Time `json:"next_run,omitempty"` // 禁用或调度器未启动时为空
+	LastRun      *time.Time `json:"last_run,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+}
+
+// job 已注册的任务及其运行状态
+type job struct {
+	Job
+	schedule cron.Schedule
+
+	mu           sync.Mutex
+	running      bool
+	nextRun      time.Time
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastError    error
+	wake         chan struct{} // 启用状态变化时唤醒调度循环
+}
+
+// Scheduler 定时任务调度器
+type Scheduler struct {
+	mu      sync.RWMutex
+	jobs    map[string]*job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+	stopped bool
+}
+
+// New 创建调度器，parent 取消时所有任务收到取消信号
+func New(parent context.Context) *Scheduler {
+	ctx, cancel := context.WithCancel(parent)
+	return &Scheduler{
+		jobs:   make(map[string]*job),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Register 注册任务，需在 Start 之前调用
+func (s *Scheduler) Register(j Job) error {
+	schedule, err := cron.ParseStandard(j.Spec)
+	if err != nil {
+		return fmt.Errorf("任务 %s 的 cron 表达式 %q 无效: %v", j.Name, j.Spec, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("调度器已启动，无法注册任务 %s", j.Name)
+	}
+	if _, ok := s.jobs[j.Name]; ok {
+		return fmt.Errorf("任务重复注册: %s", j.Name)
+	}
+	s.jobs[j.Name] = &job{Job: j, schedule: schedule, wake: make(chan struct{}, 1)}
+	return nil
+}
+
+// Start 启动所有任务的调度循环
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(j)
+	}
+}
+
+// Stop 停止调度，取消正在执行的任务并等待其结束；ctx 到期时不再等待并返回 ctx 的错误
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// track 登记一次手动执行，调度器停止后返回 ErrStopped
+func (s *Scheduler) track() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return ErrStopped
+	}
+	s.wg.Add(1)
+	return nil
+}
+
+// loop 按 cron 表达式循环触发任务
+func (s *Scheduler) loop(j *job) {
+	defer s.wg.Done()
+
+	for {
+		j.mu.Lock()
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if j.Enabled {
+			j.nextRun = j.schedule.Next(time.Now())
+			if j.Jitter > 0 {
+				j.nextRun = j.nextRun.Add(time.Duration(rand.Int63n(int64(j.Jitter))))
+			}
+			timer = time.NewTimer(time.Until(j.nextRun))
+			wait = timer.C
+		} else {
+			j.nextRun = time.Time{}
+		}
+		j.mu.Unlock()
+
+		select {
+		case <-s.ctx.Done():
+			stopTimer(timer)
+			return
+		case <-j.wake:
+			// 启用状态变化，重新计算下次执行时间
+			stopTimer(timer)
+		case <-wait:
+			if err := s.execute(j); errors.Is(err, ErrJobRunning) {
+				log.Printf("[定时任务] %s 上一次执行尚未结束，跳过本次", j.Name)
+			}
+		}
+	}
+}
+
+// stopTimer 停止计时器，nil 时忽略
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// execute 执行任务，同一任务正在执行时返回 ErrJobRunning
+func (s *Scheduler) execute(j *job) error {
+	j.mu.Lock()
+	if j.running {
+		j.mu.Unlock()
+		return ErrJobRunning
+	}
+	j.running = true
+	j.mu.Unlock()
+
+	start := time.Now()
+	err := j.Run(s.ctx)
+	if err != nil {
+		log.Printf("[定时任务] %s 执行失败: %v", j.Name, err)
+	}
+
+	j.mu.Lock()
+	j.running = false
+	j.lastRun = start
+	j.lastDuration = time.Since(start)
+	j.lastError = err
+	j.mu.Unlock()
+	return err
+}
+
+// lookup 按名称查找任务
+func (s *Scheduler) lookup(name string) (*job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return j, nil
+}
+
+// Run 立即同步执行任务（忽略启用状态），正在执行时返回 ErrJobRunning
+func (s *Scheduler) Run(name string) error {
+	j, err := s.lookup(name)
+	if err != nil {
+		return err
+	}
+	if err := s.track(); err != nil {
+		return err
+	}
+	defer s.wg.Done()
+
+	return s.execute(j)
+}
+
+// Trigger 立即在后台执行任务（忽略启用状态），正在执行时返回 ErrJobRunning
+func (s *Scheduler) Trigger(name string) error {
+	j, err := s.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	running := j.running
+	j.mu.Unlock()
+	if running {
+		return ErrJobRunning
+	}
+	if err := s.track(); err != nil {
+		return err
+	}
+
+	go func() {
+		defer s.wg.Done()
+		log.Printf("[手动任务] 执行 %s...", name)
+		s.execute(j)
+	}()
+	return nil
+}
+
+// SetEnabled 启用或禁用任务的定时执行
+func (s *Scheduler) SetEnabled(name string, enabled bool) error {
+	j, err := s.lookup(name)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	j.Enabled = enabled
+	j.mu.Unlock()
+
+	// 唤醒调度循环，已有待处理的唤醒时无需重复发送
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Enabled 判断任务是否启用
+func (s *Scheduler) Enabled(name string) bool {
+	j, err := s.lookup(name)
+	if err != nil {
+		return false
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.Enabled
+}
+
+// Jobs 返回所有任务的状态，按名称排序
+func (s *Scheduler) Jobs() []Status {
+	s.mu.RLock()
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].Name < jobs[b].Name })
+
+	statuses := make([]Status, 0, len(jobs))
+	for _, j := range jobs {
+		statuses = append(statuses, j.status())
+	}
+	return statuses
+}
+
+// status 当前任务状态快照
+func (j *job) status() Status {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	st := Status{
+		Name:    j.Name,
+		Spec:    j.Spec,
+		Jitter:  j.Jitter.String(),
+		Enabled: j.Enabled,
+		Running: j.running,
+	}
+	if !j.nextRun.IsZero() {
+		next := j.nextRun
+		st.NextRun = &next
+	}
+	if !j.lastRun.IsZero() {
+		last := j.lastRun
+		st.LastRun = &last
+		st.LastDuration = j.lastDuration.String()
+	}
+	if j.lastError != nil {
+		st.LastError = j.lastError.Error()
+	}
+	return st
+}
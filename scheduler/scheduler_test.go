@@ -0,0 +1,175 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingJob 返回一个阻塞到 release 关闭的任务函数，每次开始执行时向 started 发送信号
+func blockingJob(started chan<- struct{}, release <-chan struct{}) Func {
+	return func(ctx context.Context) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}
+}
+
+// waitFor 轮询等待 cond 成立，超时后测试失败
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobDoesNotOverlap(t *testing.T) {
+	s := New(context.Background())
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	if err := s.Register(Job{Name: "block", Spec: "@every 1h", Run: blockingJob(started, release)}); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+
+	if err := s.Trigger("block"); err != nil {
+		t.Fatalf("first Trigger: %v", err)
+	}
+	<-started
+
+	if err := s.Trigger("block"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("Trigger while running = %v, want ErrJobRunning", err)
+	}
+	if err := s.Run("block"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("Run while running = %v, want ErrJobRunning", err)
+	}
+	if !s.Jobs()[0].Running {
+		t.Error("status should report the job as running")
+	}
+
+	close(release)
+	waitFor(t, time.Second, "the job to finish", func() bool { return !s.Jobs()[0].Running })
+	if err := s.Run("block"); err != nil {
+		t.Errorf("Run after the job finished: %v", err)
+	}
+	if n := len(started); n != 1 {
+		t.Errorf("job started %d more times, want 1", n)
+	}
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScheduledRunsDoNotOverlap(t *testing.T) {
+	s := New(context.Background())
+	var running, maxRunning, runs int32
+	err := s.Register(Job{Name: "slow", Spec: "@every 1s", Enabled: true, Run: func(ctx context.Context) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		if n > atomic.LoadInt32(&maxRunning) {
+			atomic.StoreInt32(&maxRunning, n)
+		}
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(1500 * time.Millisecond) // 比执行间隔长
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+
+	waitFor(t, 3*time.Second, "the first scheduled run", func() bool { return atomic.LoadInt32(&runs) > 0 })
+	// 正在执行时手动触发被拒绝
+	if err := s.Trigger("slow"); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("Trigger during a scheduled run = %v, want ErrJobRunning", err)
+	}
+	time.Sleep(2 * time.Second)
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if m := atomic.LoadInt32(&maxRunning); m != 1 {
+		t.Errorf("up to %d runs overlapped, want 1", m)
+	}
+}
+
+func TestSetEnabledWakesLoop(t *testing.T) {
+	s := New(context.Background())
+	var runs int32
+	err := s.Register(Job{Name: "tick", Spec: "@every 1s", Run: func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	defer s.Stop(context.Background())
+
+	if st := s.Jobs()[0]; st.Enabled || st.NextRun != nil {
+		t.Fatalf("disabled job: enabled = %v, next run = %v", st.Enabled, st.NextRun)
+	}
+
+	if err := s.SetEnabled("tick", true); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, time.Second, "the next run to be scheduled", func() bool { return s.Jobs()[0].NextRun != nil })
+	waitFor(t, 3*time.Second, "a scheduled run", func() bool { return atomic.LoadInt32(&runs) > 0 })
+
+	if err := s.SetEnabled("tick", false); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, time.Second, "the next run to be cleared", func() bool { return s.Jobs()[0].NextRun == nil })
+
+	if err := s.SetEnabled("missing", true); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("SetEnabled on unknown job = %v, want ErrJobNotFound", err)
+	}
+}
+
+func TestStopWaitsForManualRuns(t *testing.T) {
+	s := New(context.Background())
+	started := make(chan struct{}, 1)
+	var finished int32
+	err := s.Register(Job{Name: "manual", Spec: "@every 1h", Run: func(ctx context.Context) error {
+		started <- struct{}{}
+		<-ctx.Done()                       // 调度器停止时收到取消信号
+		time.Sleep(200 * time.Millisecond) // 模拟收尾工作
+		atomic.StoreInt32(&finished, 1)
+		return ctx.Err()
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 未启动调度循环时手动执行同样被跟踪
+	if err := s.Trigger("manual"); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop with a short deadline = %v, want DeadlineExceeded", err)
+	}
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&finished) != 1 {
+		t.Error("Stop returned before the manual run finished")
+	}
+
+	if err := s.Trigger("manual"); !errors.Is(err, ErrStopped) {
+		t.Errorf("Trigger after Stop = %v, want ErrStopped", err)
+	}
+	if err := s.Run("manual"); !errors.Is(err, ErrStopped) {
+		t.Errorf("Run after Stop = %v, want ErrStopped", err)
+	}
+}
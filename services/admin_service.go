@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	"yuanbao/models"
+	"yuanbao/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ListCommands 管理后台按条件分页查询口令
+func ListCommands(filter repositories.CommandFilter, page, pageSize int) ([]models.Command, int64, error) {
+	return store.ListCommands(filter, (page-1)*pageSize, pageSize)
+}
+
+// AddCommand 管理员手动添加口令，displayLimit 为0时使用默认展示上限，expiresAt 为 nil 时使用默认有效期
+func AddCommand(content, source string, displayLimit int, expiresAt *time.Time) (*models.Command, error) {
+	content, parsed, err := validateContent(content)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt, err = uploadExpiresAt(expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if displayLimit < 0 {
+		return nil, ErrInvalidDisplay
+	}
+	if source == "" {
+		source = "admin"
+	}
+
+	command := &models.Command{
+		Content:      content,
+		Code:         parsed.Code,
+		AmountCents:  parsed.AmountCents,
+		Campaign:     parsed.Campaign,
+		Fingerprint:  parsed.Fingerprint,
+		Source:       source,
+		DisplayLimit: displayLimit,
+		ExpiresAt:    expiresAt,
+	}
+	err = store.Transaction(func(tx repositories.CommandStore) error {
+		if err := ensureNewFingerprint(tx, command.Fingerprint); err != nil {
+			return err
+		}
+		if err := tx.CreateCommand(command); err != nil {
+			return err
+		}
+		return transition(tx, command, models.CommandStatusActive, reasonCreated)
+	})
+	if err != nil {
+		if dup := asDuplicate(err, command.Fingerprint); dup != nil {
+			return nil, dup
+		}
+		return nil, err
+	}
+
+	return command, nil
+}
+
+// UpdateCommandDisplay 修改口令的展示上限和已展示次数，并按新的名额更新状态
+func UpdateCommandDisplay(id uint, displayLimit, displayCount *int) error {
+	if displayLimit != nil && *displayLimit < 1 {
+		return ErrInvalidDisplay
+	}
+	if displayCount != nil && *displayCount < 0 {
+		return ErrInvalidDisplay
+	}
+
+	err := store.Transaction(func(tx repositories.CommandStore) error {
+		// 先加锁确认口令存在，未修改任何字段时也要返回不存在
+		if _, err := tx.FindCommandByIDWithLock(id); err != nil {
+			return err
+		}
+		if err := tx.UpdateCommandDisplay(id, displayLimit, displayCount); err != nil {
+			return err
+		}
+		return refreshCapacity(tx, id, reasonAdmin)
+	})
+	if err == gorm.ErrRecordNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// DeleteCommands 批量删除口令，返回删除数量
+func DeleteCommands(ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, ErrInvalidInput
+	}
+
+	var deleted int64
+	err := store.Transaction(func(tx repositories.CommandStore) error {
+		var err error
+		deleted, err = tx.DeleteCommands(ids)
+		return err
+	})
+	return deleted, err
+}
+
+// TriggerCrawler 手动触发采集来源（后台执行），与定时执行互斥
+func TriggerCrawler(name string) error {
+	if _, ok := sources.Get(name); !ok {
+		return fmt.Errorf("%w: %s", ErrSourceNotFound, name)
+	}
+	return RunJob(name)
+}
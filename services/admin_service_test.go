@@ -0,0 +1,33 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestUpdateCommandDisplayChecksExistence(t *testing.T) {
+	setupSQLiteStore(t)
+
+	command, err := AddCommand("DisplayCode01", "admin", 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limit := 3
+	cases := []struct {
+		name         string
+		id           uint
+		displayLimit *int
+		want         error
+	}{
+		{"unchanged values", command.ID, &limit, nil},
+		{"no fields", command.ID, nil, nil},
+		{"missing command", command.ID + 1000, &limit, ErrNotFound},
+		{"missing command without fields", command.ID + 1000, nil, ErrNotFound},
+	}
+	for _, c := range cases {
+		if err := UpdateCommandDisplay(c.id, c.displayLimit, nil); !errors.Is(err, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+	"yuanbao/models"
+	"yuanbao/repositories"
+
+	"gorm.io/gorm"
+)
+
+// reapBatchSize 每轮回收的最大租约数
+const reapBatchSize = 100
+
+// newClaimToken 生成随机领取令牌
+func newClaimToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ConfirmClaim 确认领取（口令已被使用，计入展示次数）
+func ConfirmClaim(token string) error {
+	return finishClaim(token, models.ClaimStatusConfirmed)
+}
+
+// ReleaseClaim 归还领取（未使用，名额回到口令池）
+func ReleaseClaim(token string) error {
+	return finishClaim(token, models.ClaimStatusReleased)
+}
+
+// finishClaim 在事务中结束一个租用中的领取
+func finishClaim(token, status string) error {
+	if token == "" {
+		return ErrInvalidInput
+	}
+
+	var expired bool
+	err := store.Transaction(func(tx repositories.CommandStore) error {
+		claim, err := tx.FindClaimByTokenWithLock(token)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrClaimNotFound
+			}
+			return err
+		}
+
+		if claim.Status != models.ClaimStatusLeased {
+			return ErrClaimFinished
+		}
+
+		// 已超时但尚未被回收：直接按过期处理
+		if status == models.ClaimStatusConfirmed && time.Now().After(claim.ExpiresAt) {
+			expired = true
+			return endClaim(tx, claim, models.ClaimStatusExpired)
+		}
+
+		return endClaim(tx, claim, status)
+	})
+	if err != nil {
+		return err
+	}
+
+	if expired {
+		return ErrClaimExpired
+	}
+	return nil
+}
+
+// endClaim 结束领取并按归还/确认后的名额更新口令状态（需在事务中调用）
+func endClaim(tx repositories.CommandStore, claim *models.Claim, status string) error {
+	if err := tx.FinishClaim(claim, status); err != nil {
+		return err
+	}
+	return refreshCapacity(tx, claim.CommandID, "claim_"+status)
+}
+
+// ReapExpiredClaims 回收超时未确认的领取，返回回收数量
+func ReapExpiredClaims() (int, error) {
+	claims, err := store.FindExpiredClaims(time.Now(), reapBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	reaped := 0
+	for _, expired := range claims {
+		var released bool
+		err := store.Transaction(func(tx repositories.CommandStore) error {
+			// 重新加锁读取，避免与用户的确认/归还操作冲突
+			claim, err := tx.FindClaimByTokenWithLock(expired.Token)
+			if err != nil {
+				return err
+			}
+			if claim.Status != models.ClaimStatusLeased {
+				return nil
+			}
+			if err := endClaim(tx, claim, models.ClaimStatusExpired); err != nil {
+				return err
+			}
+			released = true
+			return nil
+		})
+		if err != nil {
+			return reaped, err
+		}
+		if released {
+			reaped++
+		}
+	}
+
+	return reaped, nil
+}
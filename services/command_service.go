@@ -3,68 +3,227 @@ package services
 import (
 	"errors"
 	"strings"
+	"time"
 	"yuanbao/config"
+	"yuanbao/crawler"
+	"yuanbao/metrics"
 	"yuanbao/models"
+	"yuanbao/parser"
 	"yuanbao/repositories"
 
 	"gorm.io/gorm"
 )
 
-// SaveCommand 保存口令（用户上传，带验证）
-func SaveCommand(content string, uploaderIP string) (*models.Command, error) {
-	// 1. 去除首尾空格
+var (
+	// store 口令存储，启动时通过 Init 注入
+	store repositories.CommandStore
+	// rules 口令校验规则
+	rules config.CommandConfig
+	// claimRules 口令领取（租约）规则
+	claimRules config.ClaimConfig
+	// feedbackRules 领取结果反馈规则
+	feedbackRules config.FeedbackConfig
+	// moderationRules 无效举报规则
+	moderationRules config.ModerationConfig
+	// crawlerTTL 爬虫口令从发布时间算起的有效期
+	crawlerTTL time.Duration
+	// sources 口令采集来源
+	sources *crawler.Registry
+	// strategies 口令选择策略及权重
+	strategies []weightedStrategy
+	// instanceID 当前实例标识，记录在爬虫执行记录上
+	instanceID string
+)
+
+// Init 注入口令存储和业务配置
+func Init(s repositories.CommandStore, cfg *config.Config) {
+	store = s
+	rules = cfg.Command
+	claimRules = cfg.Claim
+	feedbackRules = cfg.Feedback
+	moderationRules = cfg.Moderation
+	crawlerTTL = cfg.Crawler.CommandTTL
+	sources = buildSources(cfg.Crawler)
+	strategies = buildStrategies(cfg.Selection)
+	instanceID = cfg.Server.InstanceID
+}
+
+// validateContent 解析并校验口令，返回去除首尾空格后的原文和解析结果
+// 原文和口令本体分别检查最小长度（分享文案中的口令通常很短），链接检查针对口令本体
+func validateContent(content string) (string, parser.Result, error) {
+	// 1. 去除首尾空格，解析分享文案
 	content = strings.TrimSpace(content)
+	parsed := parser.Parse(content)
 
 	// 2. 长度验证
-	if len(content) < 10 {
-		return nil, errors.New("口令长度不能少于10个字符")
+	if len(content) < rules.MinLength {
+		return "", parsed, &LimitError{ErrTooShort, rules.MinLength}
+	}
+	if len(parsed.Code) < rules.MinCodeLength {
+		return "", parsed, &LimitError{ErrTooShort, rules.MinCodeLength}
 	}
-	if len(content) > 500 {
-		return nil, errors.New("口令长度不能超过500个字符")
+	if len(content) > rules.MaxLength {
+		return "", parsed, &LimitError{ErrTooLong, rules.MaxLength}
 	}
 
 	// 3. 基本内容验证
-	if strings.Contains(content, "http://") || strings.Contains(content, "https://") {
-		return nil, errors.New("口令不能包含链接")
+	if strings.Contains(parsed.Code, "http://") || strings.Contains(parsed.Code, "https://") {
+		return "", parsed, ErrContainsLink
+	}
+
+	return content, parsed, nil
+}
+
+// ensureNewFingerprint 检查去重指纹是否已存在（需在事务中调用）
+// 同一个口令换一种文案、全角半角或空白不同时提交也视为重复
+func ensureNewFingerprint(tx repositories.CommandStore, fingerprint string) error {
+	existing, err := tx.FindCommandByFingerprint(fingerprint)
+	switch {
+	case err == nil:
+		return &DuplicateError{ExistingID: existing.ID}
+	case err == gorm.ErrRecordNotFound:
+		return nil
+	default:
+		return err
 	}
+}
 
-	// 4. 保存到数据库（数据库会自动检查重复）
-	command, err := repositories.SaveCommand(content, uploaderIP)
+// asDuplicate 将重复错误转换为 DuplicateError，不是重复错误时返回 nil
+// 并发提交时唯一索引冲突的一方没有已有口令的ID，在事务外按指纹补查
+func asDuplicate(err error, fingerprint string) *DuplicateError {
+	var dup *DuplicateError
+	if errors.As(err, &dup) {
+		return dup
+	}
+	if !isDuplicateError(err) {
+		return nil
+	}
+	dup = &DuplicateError{}
+	if existing, findErr := store.FindCommandByFingerprint(fingerprint); findErr == nil {
+		dup.ExistingID = existing.ID
+	}
+	return dup
+}
+
+// rejectReasons 上传被拒绝的原因，用于监控统计
+var rejectReasons = []struct {
+	err    error
+	reason string
+}{
+	{ErrTooShort, "too_short"},
+	{ErrTooLong, "too_long"},
+	{ErrContainsLink, "contains_link"},
+	{ErrExpired, "expired"},
+	{ErrExpiryTooFar, "expiry_too_far"},
+	{ErrDuplicate, "duplicate"},
+}
+
+// rejectReason 返回上传被拒绝的统计原因，不属于校验失败或重复时为 error
+func rejectReason(err error) string {
+	for _, r := range rejectReasons {
+		if errors.Is(err, r.err) {
+			return r.reason
+		}
+	}
+	return "error"
+}
+
+// uploadExpiresAt 计算上传口令的失效时间
+// 未指定时使用 command.default_ttl（为0时不过期）；指定的时间必须晚于当前时间，且不超过 command.max_ttl
+func uploadExpiresAt(expiresAt *time.Time) (*time.Time, error) {
+	now := time.Now()
+	if expiresAt == nil {
+		if rules.DefaultTTL == 0 {
+			return nil, nil
+		}
+		t := now.Add(rules.DefaultTTL)
+		return &t, nil
+	}
+
+	if !expiresAt.After(now) {
+		return nil, ErrExpired
+	}
+	if expiresAt.After(now.Add(rules.MaxTTL)) {
+		return nil, &LimitError{ErrExpiryTooFar, rules.MaxTTL}
+	}
+	return expiresAt, nil
+}
+
+// isDuplicateError 判断是否为唯一索引冲突
+func isDuplicateError(err error) bool {
+	return strings.Contains(err.Error(), "Duplicate") || strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "UNIQUE")
+}
+
+// SaveCommand 保存口令（用户上传，带验证），expiresAt 为上传者指定的失效时间，nil 时使用默认有效期
+func SaveCommand(content string, uploaderIP string, expiresAt *time.Time) (*models.Command, error) {
+	// 1. 内容和过期时间验证
+	content, parsed, err := validateContent(content)
+	if err == nil {
+		expiresAt, err = uploadExpiresAt(expiresAt)
+	}
+	if err != nil {
+		metrics.ObserveUpload(metrics.UploadRejected, rejectReason(err))
+		return nil, err
+	}
+
+	// 2. 保存到数据库（数据库会自动检查重复）并开放领取
+	var command *models.Command
+	err = store.Transaction(func(tx repositories.CommandStore) error {
+		var err error
+		if err := ensureNewFingerprint(tx, parsed.Fingerprint); err != nil {
+			return err
+		}
+		if command, err = tx.SaveCommand(content, parsed, uploaderIP, expiresAt); err != nil {
+			return err
+		}
+		return transition(tx, command, models.CommandStatusActive, reasonCreated)
+	})
 	if err != nil {
 		// 检查是否是重复错误
-		if strings.Contains(err.Error(), "Duplicate") || strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "UNIQUE") {
-			return nil, errors.New("该口令已存在，请勿重复提交")
+		if dup := asDuplicate(err, parsed.Fingerprint); dup != nil {
+			err = dup
 		}
+		metrics.ObserveUpload(metrics.UploadRejected, rejectReason(err))
 		return nil, err
 	}
 
+	metrics.ObserveUpload(metrics.UploadAccepted, "")
 	return command, nil
 }
 
-// SaveCrawlerCommand 保存爬虫口令（无需IP）
-func SaveCrawlerCommand(content string) (*models.Command, error) {
-	// 1. 去除首尾空格
-	content = strings.TrimSpace(content)
-
-	// 2. 长度验证
-	if len(content) < 10 {
-		return nil, errors.New("口令长度不能少于10个字符")
-	}
-	if len(content) > 500 {
-		return nil, errors.New("口令长度不能超过500个字符")
+// SaveCrawlerCommand 保存爬虫口令（无需IP），记录采集来源和出处
+// 失效时间为发布时间加上 crawler.command_ttl，发布时间未知时从当前时间算起，已经失效的口令不保存
+func SaveCrawlerCommand(content, origin, originURL string, postedAt time.Time) (*models.Command, error) {
+	// 1. 内容验证
+	content, parsed, err := validateContent(content)
+	if err != nil {
+		return nil, err
 	}
 
-	// 3. 基本内容验证
-	if strings.Contains(content, "http://") || strings.Contains(content, "https://") {
-		return nil, errors.New("口令不能包含链接")
+	if postedAt.IsZero() {
+		postedAt = time.Now()
+	}
+	expiresAt := postedAt.Add(crawlerTTL)
+	if !expiresAt.After(time.Now()) {
+		return nil, ErrExpired
 	}
 
-	// 4. 保存到数据库
-	command, err := repositories.SaveCrawlerCommand(content)
+	// 2. 保存到数据库并开放领取
+	var command *models.Command
+	err = store.Transaction(func(tx repositories.CommandStore) error {
+		var err error
+		if err := ensureNewFingerprint(tx, parsed.Fingerprint); err != nil {
+			return err
+		}
+		if command, err = tx.SaveCrawlerCommand(content, parsed, origin, originURL, &expiresAt); err != nil {
+			return err
+		}
+		return transition(tx, command, models.CommandStatusActive, reasonCreated)
+	})
 	if err != nil {
 		// 检查是否是重复错误
-		if strings.Contains(err.Error(), "Duplicate") || strings.Contains(err.Error(), "duplicate") || strings.Contains(err.Error(), "UNIQUE") {
-			return nil, errors.New("该口令已存在")
+		if dup := asDuplicate(err, parsed.Fingerprint); dup != nil {
+			return nil, dup
 		}
 		return nil, err
 	}
@@ -72,62 +231,96 @@ func SaveCrawlerCommand(content string) (*models.Command, error) {
 	return command, nil
 }
 
-// GetRandomCommand 获取随机口令（排除同IP上传的，带悲观锁和事务）
-func GetRandomCommand(clientIP string) (*models.Command, error) {
+// GetRandomCommand 获取随机口令（排除同一客户端上传的，带悲观锁和事务）
+// 每次请求按 selection.strategies 的权重选择策略，所用策略记录在发放记录上
+// clientIDs 为请求者在所有密钥下的标识，第一个为当前标识，记录在租约上
+// 返回的口令只是被租用，需要通过 ConfirmClaim 确认后才计入展示次数；没有可领取的口令时返回 ErrPoolEmpty
+func GetRandomCommand(clientIDs []string) (*models.Command, *models.Claim, error) {
 	var command *models.Command
-	var err error
+	var claim *models.Claim
+	strategy := pickStrategy()
 
 	// 开启事务
-	err = config.DB.Transaction(func(tx *gorm.DB) error {
-		// 在事务中查询并锁定
-		command, err = repositories.FindRandomCommandWithLock(clientIP)
-		if err != nil {
-			return err
-		}
+	err := withClaimRetry(func() error {
+		return store.Transaction(func(tx repositories.CommandStore) error {
+			var err error
 
-		if command != nil {
-			// 更新展示次数
-			command.DisplayCount++
-			err = repositories.UpdateCommand(command)
-			if err != nil {
+			// 在事务中查询并锁定
+			command, err = tx.FindRandomCommandWithLock(clientIDs, strategy)
+			if err != nil || command == nil {
 				return err
 			}
 
-			// 暂时注释掉自动删除功能，先观察数据量
-			// 如果达到3次，立即删除
-			// if command.DisplayCount >= 3 {
-			// 	err = repositories.DeleteCommand(command.ID)
-			// 	if err != nil {
-			// 		return err
-			// 	}
-			// }
-		}
+			// 创建租约，占用一个名额
+			token, err := newClaimToken()
+			if err != nil {
+				return err
+			}
+			claim = &models.Claim{
+				Token:     token,
+				CommandID: command.ID,
+				ClientIP:  clientIDs[0],
+				Status:    models.ClaimStatusLeased,
+				ExpiresAt: time.Now().Add(claimRules.TTL),
+			}
+			if err := tx.CreateClaim(claim); err != nil {
+				command, claim = nil, nil
+				return err
+			}
+			if err := refreshCapacity(tx, command.ID, reasonLease); err != nil {
+				return err
+			}
 
-		return nil
+			// 记录发放，之后不再把该口令发给同一请求者（归还或超时也不例外）
+			return tx.RecordDelivery(command.ID, clientIDs[0], strategy.Name())
+		})
 	})
+	switch {
+	case err != nil:
+		metrics.ObserveRandomFetch(strategy.Name(), metrics.FetchError)
+		return nil, nil, err
+	case command == nil:
+		metrics.ObserveRandomFetch(strategy.Name(), metrics.FetchMiss)
+		return nil, nil, ErrPoolEmpty
+	default:
+		metrics.ObserveRandomFetch(strategy.Name(), metrics.FetchHit)
+	}
 
-	return command, err
+	return command, claim, nil
+}
+
+// maxClaimAttempts 选中的口令被并发请求抢先占满时，重新选择的最大次数
+const maxClaimAttempts = 3
+
+// withClaimRetry 执行 fn，口令名额被抢占（repositories.ErrCommandUnavailable）时重新执行
+// 最后一次仍被抢占时视为没有可用口令
+func withClaimRetry(fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if !errors.Is(err, repositories.ErrCommandUnavailable) {
+			return err
+		}
+		if attempt == maxClaimAttempts {
+			return nil
+		}
+	}
 }
 
 // GetCount 获取可用口令数量
 func GetCount() (int64, error) {
-	return repositories.CountAvailableCommands()
+	return store.CountAvailableCommands()
 }
 
-// MarkAsInvalid 标记口令为无效（直接删除）
-func MarkAsInvalid(content string) error {
-	content = strings.TrimSpace(content)
-	if content == "" {
-		return errors.New("口令内容不能为空")
-	}
-
-	err := repositories.MarkCommandAsInvalid(content)
+// PoolStats 按来源和已展示次数统计可用口令（供监控抓取）
+func PoolStats() ([]metrics.PoolStat, error) {
+	buckets, err := store.CountAvailableByBucket()
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return errors.New("口令不存在或已被删除")
-		}
-		return err
+		return nil, err
 	}
 
-	return nil
+	stats := make([]metrics.PoolStat, 0, len(buckets))
+	for _, b := range buckets {
+		stats = append(stats, metrics.PoolStat{Source: b.Source, DisplayCount: b.DisplayCount, Count: b.Count})
+	}
+	return stats, nil
 }
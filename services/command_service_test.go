@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"yuanbao/config"
+	"yuanbao/models"
+	"yuanbao/repositories"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupSQLiteStore 在临时目录中创建 SQLite 数据库并初始化服务
+// 连接参数与 config.InitDB 一致：BEGIN IMMEDIATE 事务和写锁等待
+func setupSQLiteStore(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_txlock=immediate&_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Default()
+	opts := repositories.StoreOptions{MaxDisplayCount: cfg.Command.MaxDisplayCount}
+	if err := repositories.AutoMigrate(db, opts); err != nil {
+		t.Fatal(err)
+	}
+	Init(repositories.NewSQLiteStore(db, opts), cfg)
+	return db
+}
+
+// TestConcurrentClaimsNeverExceedDisplayLimit 并发获取并确认口令，任何口令的展示次数加租用数都不能超过展示上限
+func TestConcurrentClaimsNeverExceedDisplayLimit(t *testing.T) {
+	db := setupSQLiteStore(t)
+
+	const commands = 5
+	for i := 0; i < commands; i++ {
+		if _, err := AddCommand(fmt.Sprintf("ConcurrencyCode%02d", i), "admin", 0, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	const clients = 40
+	var confirmed int64
+	var wg sync.WaitGroup
+	for i := 0; i < clients; i++ {
+		wg.Add(1)
+		go func(client string) {
+			defer wg.Done()
+			for j := 0; j < commands; j++ {
+				_, claim, err := GetRandomCommand([]string{client})
+				if errors.Is(err, ErrPoolEmpty) {
+					return
+				}
+				if err != nil {
+					t.Errorf("GetRandomCommand: %v", err)
+					return
+				}
+				if err := ConfirmClaim(claim.Token); err != nil {
+					t.Errorf("ConfirmClaim: %v", err)
+					return
+				}
+				atomic.AddInt64(&confirmed, 1)
+			}
+		}(fmt.Sprintf("h:client-%d", i))
+	}
+	wg.Wait()
+
+	var rows []models.Command
+	if err := db.Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	var displayed int64
+	for _, c := range rows {
+		if c.DisplayCount+c.LeasedCount > c.DisplayLimit {
+			t.Errorf("command #%d: display_count %d + leased_count %d > display_limit %d",
+				c.ID, c.DisplayCount, c.LeasedCount, c.DisplayLimit)
+		}
+		displayed += int64(c.DisplayCount)
+	}
+	if displayed != confirmed {
+		t.Errorf("sum of display_count = %d, confirmed claims = %d", displayed, confirmed)
+	}
+	if confirmed == 0 {
+		t.Error("no claim was confirmed")
+	}
+	if limit := int64(commands * config.Default().Command.MaxDisplayCount); confirmed > limit {
+		t.Errorf("confirmed %d claims, more than %d available slots", confirmed, limit)
+	}
+}
+
+// TestExpiredCommandCanBeUploadedAgain 过期的口令不再占用去重指纹，同一口令可以重新上传
+func TestExpiredCommandCanBeUploadedAgain(t *testing.T) {
+	db := setupSQLiteStore(t)
+
+	first, err := SaveCommand("ReuploadCode01", "h:uploader", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SaveCommand("ＲｅｕｐｌｏａｄＣｏｄｅ０１", "h:uploader", nil); !errors.Is(err, ErrDuplicate) {
+		t.Fatalf("uploading a live duplicate: got %v, want ErrDuplicate", err)
+	}
+
+	if err := db.Model(first).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ExpireDueCommands(); err != nil || n != 1 {
+		t.Fatalf("ExpireDueCommands = %d, %v", n, err)
+	}
+
+	second, err := SaveCommand("ReuploadCode01", "h:uploader", nil)
+	if err != nil {
+		t.Fatalf("uploading an expired command again: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Fatalf("expected a new command, got #%d again", second.ID)
+	}
+}
+
+// TestReportAfterReuploadTargetsLiveCommand 口令过期后重新上传，举报应计入新的口令而不是过期的旧口令
+func TestReportAfterReuploadTargetsLiveCommand(t *testing.T) {
+	db := setupSQLiteStore(t)
+
+	first, err := SaveCommand("ReportedAgain01", "h:uploader", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(first).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExpireDueCommands(); err != nil {
+		t.Fatal(err)
+	}
+	second, err := SaveCommand("ReportedAgain01", "h:uploader", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < moderationRules.ReportThreshold; i++ {
+		if _, err := ReportInvalid("ReportedAgain01", fmt.Sprintf("h:reporter-%d", i)); err != nil {
+			t.Fatalf("ReportInvalid: %v", err)
+		}
+	}
+
+	var old, live models.Command
+	if err := db.First(&old, first.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.First(&live, second.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if old.ReportCount != 0 {
+		t.Errorf("expired command #%d got %d reports, want 0", old.ID, old.ReportCount)
+	}
+	if live.Status != models.CommandStatusQuarantined {
+		t.Errorf("live command #%d status = %s, want quarantined", live.ID, live.Status)
+	}
+}
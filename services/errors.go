@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 业务错误，调用方通过 errors.Is 判断；返回时可以用 fmt.Errorf("%w") 或下面的错误类型附带详情
+// 错误文本只用于日志，面向用户的错误码和提示文案由接口层（controllers）统一生成
+var (
+	// ErrInvalidInput 参数不合法
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrEmptyContent 口令内容为空
+	ErrEmptyContent = errors.New("empty content")
+	// ErrTooShort 原文短于 command.min_length 或口令本体短于 command.min_code_length，详情见 LimitError
+	ErrTooShort = errors.New("command too short")
+	// ErrTooLong 原文长于 command.max_length，详情见 LimitError
+	ErrTooLong = errors.New("command too long")
+	// ErrContainsLink 口令本体包含链接
+	ErrContainsLink = errors.New("command contains link")
+	// ErrExpired 失效时间已过
+	ErrExpired = errors.New("command expired")
+	// ErrExpiryTooFar 失效时间超过 command.max_ttl，详情见 LimitError
+	ErrExpiryTooFar = errors.New("expiry too far")
+	// ErrDuplicate 与已有口令重复，详情见 DuplicateError
+	ErrDuplicate = errors.New("duplicate command")
+	// ErrNotFound 口令不存在（或不在要求的状态，如恢复时不在隔离区）
+	ErrNotFound = errors.New("command not found")
+	// ErrPoolEmpty 没有可领取的口令
+	ErrPoolEmpty = errors.New("command pool empty")
+	// ErrInvalidDisplay 展示上限或已展示次数不合法
+	ErrInvalidDisplay = errors.New("invalid display count")
+	// ErrSourceNotFound 采集来源不存在
+	ErrSourceNotFound = errors.New("crawler source not found")
+	// ErrClaimNotFound 领取记录不存在
+	ErrClaimNotFound = errors.New("claim not found")
+	// ErrClaimFinished 领取已结束（已确认、归还或回收）
+	ErrClaimFinished = errors.New("claim already finished")
+	// ErrClaimExpired 确认时领取已超时
+	ErrClaimExpired = errors.New("claim expired")
+	// ErrInvalidOutcome 反馈结果不在 models.IsValidOutcome 允许的范围内
+	ErrInvalidOutcome = errors.New("invalid feedback outcome")
+	// ErrFeedbackExists 该领取已反馈过
+	ErrFeedbackExists = errors.New("feedback already submitted")
+	// ErrQuarantined 口令已在隔离区
+	ErrQuarantined = errors.New("command quarantined")
+	// ErrOwnCommand 举报自己上传的口令
+	ErrOwnCommand = errors.New("cannot report own command")
+	// ErrAlreadyReported 已举报过该口令
+	ErrAlreadyReported = errors.New("already reported")
+	// ErrInvalidTransition 口令当前状态不允许迁移到目标状态
+	ErrInvalidTransition = errors.New("invalid status transition")
+)
+
+// LimitError 超出配置限制的错误，Limit 为对应的限制值（最小/最大长度、最长有效期），供生成提示文案
+type LimitError struct {
+	Err   error
+	Limit interface{}
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v (limit %v)", e.Err, e.Limit)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// DuplicateError 口令与已有口令重复（去重指纹相同），ExistingID 为已有口令的ID
+// errors.Is(err, ErrDuplicate) 成立
+type DuplicateError struct {
+	ExistingID uint
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%v #%d", ErrDuplicate, e.ExistingID)
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicate
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"log"
+	"yuanbao/models"
+	"yuanbao/repositories"
+
+	"gorm.io/gorm"
+)
+
+// SubmitFeedback 提交领取结果反馈
+// 成功反馈会确认仍在租用中的领取；失败反馈会归还名额，并在失败次数达到阈值后下架口令
+func SubmitFeedback(token, outcome, reporterIP string) error {
+	if token == "" {
+		return ErrInvalidInput
+	}
+	if !models.IsValidOutcome(outcome) {
+		return ErrInvalidOutcome
+	}
+
+	failure := isFailureOutcome(outcome)
+
+	return store.Transaction(func(tx repositories.CommandStore) error {
+		claim, err := tx.FindClaimByTokenWithLock(token)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrClaimNotFound
+			}
+			return err
+		}
+
+		existing, err := tx.FindFeedbackByClaim(claim.ID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return ErrFeedbackExists
+		}
+
+		// 领取仍在租用中：成功即确认，失败则归还名额（没有真正领到红包）
+		if claim.Status == models.ClaimStatusLeased {
+			status := models.ClaimStatusConfirmed
+			if failure {
+				status = models.ClaimStatusReleased
+			}
+			if err := endClaim(tx, claim, status); err != nil {
+				return err
+			}
+		}
+
+		err = tx.CreateFeedback(&models.CommandFeedback{
+			CommandID:  claim.CommandID,
+			ClaimID:    claim.ID,
+			Outcome:    outcome,
+			ReporterIP: reporterIP,
+		}, failure)
+		if err != nil {
+			return err
+		}
+
+		if !failure {
+			return nil
+		}
+
+		command, err := tx.FindCommandByIDWithLock(claim.CommandID)
+		if err == gorm.ErrRecordNotFound {
+			return nil // 口令已被清理
+		}
+		if err != nil {
+			return err
+		}
+
+		// 已隔离或过期的口令不再下架
+		if models.CanTransition(command.Status, models.CommandStatusRetired) && command.FailureCount >= feedbackRules.RetireAfterFailures {
+			log.Printf("口令 #%d 失败反馈达到 %d 次，下架", command.ID, command.FailureCount)
+			return transition(tx, command, models.CommandStatusRetired, reasonFeedback)
+		}
+		return nil
+	})
+}
+
+// isFailureOutcome 判断反馈结果是否计为失败
+func isFailureOutcome(outcome string) bool {
+	for _, o := range feedbackRules.FailureOutcomes {
+		if o == outcome {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	"yuanbao/models"
+	"yuanbao/repositories"
+
+	"gorm.io/gorm"
+)
+
+// 状态变化原因，记录在 models.CommandTransition 上
+const (
+	reasonCreated  = "created"  // 新口令写入
+	reasonLease    = "lease"    // 被领取，占用一个名额
+	reasonFeedback = "feedback" // 失败反馈达到阈值
+	reasonReport   = "report"   // 举报达到阈值
+	reasonRestore  = "restore"  // 管理员从隔离区恢复
+	reasonAdmin    = "admin"    // 管理员修改展示次数
+	reasonExpiry   = "expiry"   // 到达失效时间
+)
+
+// expireBatchSize 每轮过期处理的最大口令数
+const expireBatchSize = 100
+
+// transition 将口令迁移到 to 状态（需在事务中调用），已处于该状态时不做任何修改
+func transition(tx repositories.CommandStore, command *models.Command, to, reason string) error {
+	if command.Status == to {
+		return nil
+	}
+	if !models.CanTransition(command.Status, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, command.Status, to)
+	}
+	return tx.UpdateCommandStatus(command, to, reason)
+}
+
+// syncCapacity 名额变化后在 active、leased、exhausted 之间切换
+// 隔离、下架、过期的口令不因名额变化而改变状态
+func syncCapacity(tx repositories.CommandStore, command *models.Command, reason string) error {
+	switch command.Status {
+	case models.CommandStatusActive, models.CommandStatusLeased, models.CommandStatusExhausted:
+		return transition(tx, command, command.CapacityStatus(), reason)
+	}
+	return nil
+}
+
+// refreshCapacity 重新加锁读取口令并同步名额状态，口令已被删除时忽略
+func refreshCapacity(tx repositories.CommandStore, id uint, reason string) error {
+	command, err := tx.FindCommandByIDWithLock(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return syncCapacity(tx, command, reason)
+}
+
+// ListTransitions 查询口令的状态变化记录
+func ListTransitions(id uint) ([]models.CommandTransition, error) {
+	if _, err := store.FindCommandByID(id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return store.ListCommandTransitions(id)
+}
+
+// ExpireDueCommands 将到达失效时间的口令标记为过期（expired 状态），返回处理数量
+// 过期的口令保留在库中供管理后台查询，到期后、处理前的口令也不会被随机选中
+func ExpireDueCommands() (int, error) {
+	expired := 0
+	for {
+		commands, err := store.FindCommandsToExpire(time.Now(), expireBatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, c := range commands {
+			err := store.Transaction(func(tx repositories.CommandStore) error {
+				command, err := tx.FindCommandByIDWithLock(c.ID)
+				if err != nil {
+					return err
+				}
+				return transition(tx, command, models.CommandStatusExpired, reasonExpiry)
+			})
+			if err != nil {
+				return expired, fmt.Errorf("口令 #%d: %w", c.ID, err)
+			}
+			expired++
+		}
+
+		if len(commands) < expireBatchSize {
+			return expired, nil
+		}
+	}
+}
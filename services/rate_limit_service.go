@@ -0,0 +1,18 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// CleanExpiredRateLimits 删除数据库中已恢复初始值的限流状态
+func CleanExpiredRateLimits() error {
+	count, err := store.DeleteExpiredRateLimits(time.Now())
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("清理 %d 条过期限流状态", count)
+	}
+	return nil
+}
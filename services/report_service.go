@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"yuanbao/models"
+	"yuanbao/parser"
+	"yuanbao/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ReportInvalid 举报无效口令，content 可以是获取到的口令或完整的分享文案
+// 同一IP对同一口令只记录一次，不同IP的举报达到阈值后口令进入隔离区，由管理员审核恢复或删除
+// 返回值表示口令是否因本次举报被隔离
+func ReportInvalid(content string, reporterIP string) (bool, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return false, ErrEmptyContent
+	}
+
+	var quarantined bool
+	err := store.Transaction(func(tx repositories.CommandStore) error {
+		command, err := findReportedCommand(tx, content)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		if command.Status == models.CommandStatusQuarantined {
+			return ErrQuarantined
+		}
+
+		// 上传者本人的举报不计入阈值，避免单方决定
+		if command.UploaderIP != "" && command.UploaderIP == reporterIP {
+			return ErrOwnCommand
+		}
+
+		reported, err := tx.HasReport(command.ID, reporterIP)
+		if err != nil {
+			return err
+		}
+		if reported {
+			return ErrAlreadyReported
+		}
+
+		err = tx.CreateReport(&models.CommandReport{
+			CommandID:  command.ID,
+			ReporterIP: reporterIP,
+		})
+		if err != nil {
+			if isDuplicateError(err) {
+				return ErrAlreadyReported // 同一IP并发举报，唯一索引拦截了后提交的一方
+			}
+			return err
+		}
+
+		// 重新加锁读取，以数据库中的举报次数判断阈值：并发举报时读取到的次数可能已经过时
+		command, err = tx.FindCommandByIDWithLock(command.ID)
+		if err != nil {
+			return err
+		}
+
+		// 已下架或过期的口令不会再被发放，已被并发的举报隔离的也无需处理，只记录举报
+		if command.ReportCount >= moderationRules.ReportThreshold && models.CanTransition(command.Status, models.CommandStatusQuarantined) {
+			err := transition(tx, command, models.CommandStatusQuarantined, reasonReport)
+			if errors.Is(err, repositories.ErrStatusChanged) {
+				return nil // 状态已被其他事务修改，举报已记录，下一次举报时重新判断
+			}
+			if err != nil {
+				return err
+			}
+			log.Printf("口令 #%d 被 %d 个IP举报，进入隔离区", command.ID, command.ReportCount)
+			quarantined = true
+		}
+		return nil
+	})
+
+	return quarantined, err
+}
+
+// findReportedCommand 查询被举报的口令
+// 通常举报的是获取到的口令本体，直接匹配；匹配不到时按分享文案解析后用去重指纹再查
+func findReportedCommand(tx repositories.CommandStore, content string) (*models.Command, error) {
+	command, err := tx.FindCommandByCode(content)
+	if err != gorm.ErrRecordNotFound {
+		return command, err
+	}
+	return tx.FindCommandByFingerprint(parser.Parse(content).Fingerprint)
+}
+
+// ListQuarantined 分页查询隔离区口令
+func ListQuarantined(page, pageSize int) ([]models.Command, int64, error) {
+	return store.ListQuarantinedCommands((page-1)*pageSize, pageSize)
+}
+
+// RestoreQuarantined 恢复被隔离的口令，按名额使用情况回到 active/leased/exhausted，并清空举报记录
+func RestoreQuarantined(id uint) error {
+	err := store.Transaction(func(tx repositories.CommandStore) error {
+		command, err := tx.FindCommandByIDWithLock(id)
+		if err != nil {
+			return err
+		}
+		if command.Status != models.CommandStatusQuarantined {
+			return gorm.ErrRecordNotFound
+		}
+
+		if err := transition(tx, command, command.CapacityStatus(), reasonRestore); err != nil {
+			return err
+		}
+		return tx.ClearReports(id)
+	})
+	if err == gorm.ErrRecordNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// PurgeQuarantined 彻底删除被隔离的口令
+func PurgeQuarantined(id uint) error {
+	err := store.Transaction(func(tx repositories.CommandStore) error {
+		return tx.PurgeCommand(id)
+	})
+	if err == gorm.ErrRecordNotFound {
+		return ErrNotFound
+	}
+	return err
+}
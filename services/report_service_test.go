@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"yuanbao/models"
+)
+
+// TestConcurrentReportsQuarantineOnce 并发举报时按数据库中的举报次数判断阈值，口令只被隔离一次
+func TestConcurrentReportsQuarantineOnce(t *testing.T) {
+	db := setupSQLiteStore(t)
+
+	command, err := AddCommand("ReportedCode01", "admin", 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const reporters = 20
+	var mu sync.Mutex
+	recorded, quarantinedBy := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < reporters; i++ {
+		wg.Add(1)
+		go func(reporter string) {
+			defer wg.Done()
+			quarantined, err := ReportInvalid(command.Code, reporter)
+			if err != nil && !errors.Is(err, ErrQuarantined) {
+				t.Errorf("ReportInvalid: %v", err)
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				recorded++
+			}
+			if quarantined {
+				quarantinedBy++
+			}
+		}(fmt.Sprintf("h:reporter-%d", i))
+	}
+	wg.Wait()
+
+	var got models.Command
+	if err := db.First(&got, command.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.CommandStatusQuarantined {
+		t.Errorf("status = %s, want quarantined", got.Status)
+	}
+	if quarantinedBy != 1 {
+		t.Errorf("%d reports quarantined the command, want 1", quarantinedBy)
+	}
+	if got.ReportCount != recorded {
+		t.Errorf("report_count = %d, recorded reports = %d", got.ReportCount, recorded)
+	}
+	if threshold := moderationRules.ReportThreshold; recorded < threshold {
+		t.Errorf("recorded %d reports, fewer than the threshold %d", recorded, threshold)
+	}
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"log"
+	"time"
+)
+
+// purgeBatchSize 每个事务删除的最大口令数
+const purgeBatchSize = 500
+
+// PurgeExpiredIdentities 清空超过保留期的客户端标识
+func PurgeExpiredIdentities(retention time.Duration) error {
+	count, err := store.PurgeIdentities(time.Now().Add(-retention))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("清空 %d 条记录中超过保留期的客户端标识", count)
+	}
+	return nil
+}
+
+// PurgeOldCommands 删除过期、下架超过保留期的口令及其关联记录，返回删除的口令数
+// 分批在多个事务中删除，避免长时间持有写锁
+func PurgeOldCommands(retention time.Duration) (int64, error) {
+	before := time.Now().Add(-retention)
+	var total int64
+	for {
+		count, err := store.PurgeCommands(before, purgeBatchSize)
+		total += count
+		if err != nil || count < purgeBatchSize {
+			return total, err
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	"yuanbao/config"
+	"yuanbao/scheduler"
+)
+
+// 内置定时任务名称（爬虫任务使用采集来源名称）
+const (
+	JobClaimReaper    = "claim_reaper"       // 回收超时租约
+	JobExpirySweeper  = "expiry_sweeper"     // 将到期口令标记为过期
+	JobRateLimitClean = "rate_limit_cleanup" // 清理数据库中过期的限流状态
+	JobIdentityPurge  = "identity_retention" // 清空超过保留期的客户端标识
+	JobCommandPurge   = "command_retention"  // 删除过期、下架超过保留期的口令
+)
+
+// jobs 定时任务调度器，启动时通过 StartScheduler 创建
+var jobs *scheduler.Scheduler
+
+// StartScheduler 注册并启动所有定时任务，ctx 取消时所有任务收到取消信号
+// 每个任务有默认的 cron 表达式和启用状态，可通过 scheduler.jobs 按任务名覆盖
+func StartScheduler(ctx context.Context, cfg *config.Config) error {
+	// 上次退出时未完成的执行记录，必须在采集任务启动前处理，否则会把本次启动后的执行也标记为中断
+	// 只处理本实例的记录，共享数据库的其他实例可能正在执行
+	if count, err := store.InterruptCrawlerRuns(instanceID); err != nil {
+		log.Printf("更新中断的执行记录失败: %v", err)
+	} else if count > 0 {
+		log.Printf("%d 条执行记录因服务重启被标记为中断", count)
+	}
+
+	jobs = scheduler.New(ctx)
+	known := make(map[string]bool)
+
+	register := func(name, spec string, enabled bool, run scheduler.Func) error {
+		job := scheduler.Job{
+			Name:    name,
+			Spec:    spec,
+			Jitter:  cfg.Scheduler.Jitter,
+			Enabled: enabled,
+			Run:     run,
+		}
+		if override, ok := cfg.Scheduler.Jobs[name]; ok {
+			if override.Spec != "" {
+				job.Spec = override.Spec
+			}
+			if override.Jitter != nil {
+				job.Jitter = *override.Jitter
+			}
+			if override.Enabled != nil {
+				job.Enabled = *override.Enabled
+			}
+		}
+		known[name] = true
+		return jobs.Register(job)
+	}
+
+	// 1. 租约回收
+	err := register(JobClaimReaper, every(cfg.Claim.ReapInterval), true, func(ctx context.Context) error {
+		count, err := ReapExpiredClaims()
+		if err == nil && count > 0 {
+			log.Printf("回收 %d 个超时未确认的领取", count)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 2. 每个采集来源一个任务，爬虫禁用时注册为禁用状态，可在管理后台启用
+	crawlerJobs := make([]string, 0)
+	for _, src := range sources.All() {
+		src := src
+		err := register(src.Name(), every(src.Schedule()), cfg.Crawler.Enabled, func(ctx context.Context) error {
+			return runSource(ctx, src)
+		})
+		if err != nil {
+			return err
+		}
+		crawlerJobs = append(crawlerJobs, src.Name())
+	}
+
+	// 3. 到期口令标记为过期
+	err = register(JobExpirySweeper, every(cfg.Command.SweepInterval), true, func(ctx context.Context) error {
+		count, err := ExpireDueCommands()
+		if count > 0 {
+			log.Printf("%d 个口令已到失效时间，标记为过期", count)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 4. 清理限流状态（仅数据库存储需要，内存存储自行清理）
+	err = register(JobRateLimitClean, every(10*time.Minute), cfg.RateLimit.Store == config.RateLimitStoreDatabase, func(ctx context.Context) error {
+		return CleanExpiredRateLimits()
+	})
+	if err != nil {
+		return err
+	}
+
+	// 5. 客户端标识保留期
+	retention := cfg.Identity.Retention
+	err = register(JobIdentityPurge, every(time.Hour), true, func(ctx context.Context) error {
+		return PurgeExpiredIdentities(retention)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 6. 历史口令保留期，command.retention 为 0 时默认禁用
+	commandRetention := cfg.Command.Retention
+	err = register(JobCommandPurge, every(time.Hour), commandRetention > 0, func(ctx context.Context) error {
+		count, err := PurgeOldCommands(commandRetention)
+		if count > 0 {
+			log.Printf("删除 %d 个过期、下架超过保留期的口令", count)
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for name := range cfg.Scheduler.Jobs {
+		if !known[name] {
+			log.Printf("警告: scheduler.jobs 中的任务 %s 不存在，已忽略", name)
+		}
+	}
+
+	jobs.Start()
+
+	log.Println("========================================")
+	log.Println("启动定时任务系统")
+	log.Println("========================================")
+	if len(cfg.Crawler.Cookies) == 0 {
+		log.Println("- 未配置贴吧Cookie（crawler.cookies），跳过贴吧来源")
+	}
+	for _, st := range jobs.Jobs() {
+		state := "已禁用"
+		if st.Enabled {
+			state = "已启用"
+		}
+		log.Printf("- %s：%s（%s）", st.Name, st.Spec, state)
+	}
+	log.Println("========================================")
+
+	// 启动后依次执行一次已启用的爬虫任务
+	go func() {
+		if !sleepContext(ctx, cfg.Crawler.StartupDelay) { // 等待服务器启动完成
+			return
+		}
+		ran := false
+		for _, name := range crawlerJobs {
+			if !jobs.Enabled(name) {
+				continue
+			}
+			if ran && !sleepContext(ctx, 10*time.Second) { // 来源之间间隔10秒
+				return
+			}
+			ran = true
+			log.Printf("\n[启动任务] 执行 %s...", name)
+			if err := jobs.Run(name); errors.Is(err, scheduler.ErrJobRunning) {
+				log.Printf("%s 正在执行，跳过启动任务", name)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// StopScheduler 停止所有定时任务，等待正在执行的任务退出，最多等到 ctx 到期
+func StopScheduler(ctx context.Context) error {
+	if jobs == nil {
+		return nil
+	}
+	return jobs.Stop(ctx)
+}
+
+// sleepContext 等待 d，ctx 提前取消时返回 false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// every 将固定间隔转换为 cron 描述符
+func every(interval time.Duration) string {
+	return fmt.Sprintf("@every %s", interval)
+}
+
+// ListJobs 返回所有定时任务的状态
+func ListJobs() []scheduler.Status {
+	return jobs.Jobs()
+}
+
+// RunJob 立即在后台执行定时任务
+func RunJob(name string) error {
+	return jobs.Trigger(name)
+}
+
+// SetJobEnabled 启用或禁用定时任务
+func SetJobEnabled(name string, enabled bool) error {
+	return jobs.SetEnabled(name, enabled)
+}
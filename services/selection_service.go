@@ -0,0 +1,56 @@
+package services
+
+import (
+	"math/rand"
+	"sort"
+	"yuanbao/config"
+	"yuanbao/repositories"
+)
+
+// weightedStrategy 带权重的选择策略
+type weightedStrategy struct {
+	strategy repositories.SelectionStrategy
+	weight   int
+}
+
+// buildStrategies 按配置创建选择策略列表（按名称排序，结果稳定），未配置时只使用 user_first
+// 策略名已由配置校验，这里忽略未知名称
+func buildStrategies(cfg config.SelectionConfig) []weightedStrategy {
+	weights := cfg.Strategies
+	if len(weights) == 0 {
+		weights = map[string]int{config.StrategyUserFirst: 1}
+	}
+
+	names := make([]string, 0, len(weights))
+	for name := range weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]weightedStrategy, 0, len(names))
+	for _, name := range names {
+		strategy, ok := repositories.LookupStrategy(name)
+		if !ok || weights[name] <= 0 {
+			continue
+		}
+		result = append(result, weightedStrategy{strategy: strategy, weight: weights[name]})
+	}
+	return result
+}
+
+// pickStrategy 按权重随机选择本次请求使用的策略
+func pickStrategy() repositories.SelectionStrategy {
+	total := 0
+	for _, s := range strategies {
+		total += s.weight
+	}
+
+	n := rand.Intn(total)
+	for _, s := range strategies {
+		if n < s.weight {
+			return s.strategy
+		}
+		n -= s.weight
+	}
+	return strategies[len(strategies)-1].strategy
+}
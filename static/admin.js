@@ -0,0 +1,312 @@
+const ADMIN_API = '/api/admin';
+const AUTH_KEY = 'yuanbao_admin_auth';
+
+let currentPage = 1;
+const pageSize = 20;
+
+document.addEventListener('DOMContentLoaded', () => {
+    if (sessionStorage.getItem(AUTH_KEY)) {
+        enterAdmin();
+    }
+});
+
+// 登录：保存 Authorization 头，验证通过后进入管理页
+document.getElementById('loginBtn').addEventListener('click', async () => {
+    const token = document.getElementById('tokenInput').value.trim();
+    const user = document.getElementById('userInput').value.trim();
+    const pass = document.getElementById('passInput').value;
+
+    if (token) {
+        sessionStorage.setItem(AUTH_KEY, `Bearer ${token}`);
+    } else if (user && pass) {
+        sessionStorage.setItem(AUTH_KEY, `Basic ${btoa(unescape(encodeURIComponent(`${user}:${pass}`)))}`);
+    } else {
+        showMessage(document.getElementById('loginMessage'), '请填写令牌或账号密码', 'error');
+        return;
+    }
+
+    enterAdmin();
+});
+
+document.getElementById('logoutBtn').addEventListener('click', () => {
+    sessionStorage.removeItem(AUTH_KEY);
+    location.reload();
+});
+
+// 进入管理页
+async function enterAdmin() {
+    const ok = await loadCommands();
+    if (!ok) {
+        return;
+    }
+    document.getElementById('loginPanel').hidden = true;
+    document.getElementById('adminPanel').hidden = false;
+    document.getElementById('logoutBtn').hidden = false;
+    loadQuarantine();
+    loadJobs();
+}
+
+// 带鉴权的请求，返回解析后的 JSON；未授权时回到登录页
+async function adminFetch(path, options = {}) {
+    const response = await fetch(`${ADMIN_API}${path}`, {
+        ...options,
+        headers: {
+            'Content-Type': 'application/json',
+            'Authorization': sessionStorage.getItem(AUTH_KEY) || '',
+            ...(options.headers || {}),
+        },
+    });
+
+    if (response.status === 401) {
+        sessionStorage.removeItem(AUTH_KEY);
+        document.getElementById('loginPanel').hidden = false;
+        document.getElementById('adminPanel').hidden = true;
+        showMessage(document.getElementById('loginMessage'), '认证失败，请重新登录', 'error');
+        return null;
+    }
+
+    return response.json();
+}
+
+// 读取查询条件
+function buildQuery() {
+    const params = new URLSearchParams({ page: currentPage, page_size: pageSize });
+    const fields = {
+        source: 'filterSource',
+        status: 'filterStatus',
+        uploader_ip: 'filterIP',
+        q: 'filterKeyword',
+        min_display: 'filterMinDisplay',
+        max_display: 'filterMaxDisplay',
+        min_age: 'filterMinAge',
+        max_age: 'filterMaxAge',
+    };
+    for (const [param, id] of Object.entries(fields)) {
+        const value = document.getElementById(id).value.trim();
+        if (value) {
+            params.set(param, value);
+        }
+    }
+    return params.toString();
+}
+
+// 加载口令列表
+async function loadCommands() {
+    const data = await adminFetch(`/commands?${buildQuery()}`);
+    if (!data) {
+        return false;
+    }
+    if (!data.success) {
+        showAdminMessage(data.message || '查询失败', 'error');
+        return true;
+    }
+
+    const rows = document.getElementById('commandRows');
+    rows.innerHTML = data.items.map((item) => `
+        <tr>
+            <td><input type="checkbox" class="row-check" value="${item.id}"></td>
+            <td>${item.id}</td>
+            <td class="admin-content" title="${escapeHtml(item.content)}">${escapeHtml(item.code || item.content)}</td>
+            <td title="${escapeHtml(item.origin_url || '')}">${escapeHtml(item.origin ? `${item.source} / ${item.origin}` : item.source)}</td>
+            <td>${escapeHtml(item.uploader_ip || '')}</td>
+            <td>${item.display_count} / ${item.display_limit}</td>
+            <td>${escapeHtml(item.status)}</td>
+            <td>${new Date(item.created_at).toLocaleString()}</td>
+            <td>${item.expires_at ? new Date(item.expires_at).toLocaleString() : '-'}</td>
+            <td><button class="admin-btn" onclick="editLimit(${item.id}, ${item.display_limit})">修改上限</button></td>
+        </tr>
+    `).join('');
+
+    const totalPages = Math.max(1, Math.ceil(data.total / pageSize));
+    document.getElementById('pageInfo').textContent = `第 ${data.page} / ${totalPages} 页，共 ${data.total} 条`;
+    document.getElementById('prevBtn').disabled = currentPage <= 1;
+    document.getElementById('nextBtn').disabled = currentPage >= totalPages;
+    document.getElementById('selectAll').checked = false;
+    return true;
+}
+
+// 加载隔离区
+async function loadQuarantine() {
+    const data = await adminFetch('/quarantine?page_size=100');
+    if (!data || !data.success) {
+        return;
+    }
+
+    document.getElementById('quarantineRows').innerHTML = data.items.map((item) => `
+        <tr>
+            <td>${item.id}</td>
+            <td class="admin-content">${escapeHtml(item.content)}</td>
+            <td>${item.report_count}</td>
+            <td>${new Date(item.quarantined_at).toLocaleString()}</td>
+            <td>
+                <button class="admin-btn" onclick="restoreCommand(${item.id})">恢复</button>
+                <button class="admin-btn danger" onclick="purgeCommand(${item.id})">删除</button>
+            </td>
+        </tr>
+    `).join('');
+}
+
+document.getElementById('searchBtn').addEventListener('click', () => {
+    currentPage = 1;
+    loadCommands();
+});
+
+document.getElementById('prevBtn').addEventListener('click', () => {
+    currentPage--;
+    loadCommands();
+});
+
+document.getElementById('nextBtn').addEventListener('click', () => {
+    currentPage++;
+    loadCommands();
+});
+
+document.getElementById('selectAll').addEventListener('change', (event) => {
+    document.querySelectorAll('.row-check').forEach((box) => {
+        box.checked = event.target.checked;
+    });
+});
+
+// 批量删除
+document.getElementById('bulkDeleteBtn').addEventListener('click', async () => {
+    const ids = [...document.querySelectorAll('.row-check:checked')].map((box) => Number(box.value));
+    if (ids.length === 0) {
+        showAdminMessage('请先勾选要删除的口令', 'error');
+        return;
+    }
+    if (!confirm(`确定删除 ${ids.length} 条口令？`)) {
+        return;
+    }
+
+    const data = await adminFetch('/commands/bulk-delete', {
+        method: 'POST',
+        body: JSON.stringify({ ids }),
+    });
+    if (data) {
+        showAdminMessage(data.success ? `已删除 ${data.deleted} 条` : data.message, data.success ? 'success' : 'error');
+        loadCommands();
+    }
+});
+
+// 修改展示上限
+async function editLimit(id, current) {
+    const value = prompt('新的展示上限', current);
+    if (value === null) {
+        return;
+    }
+
+    const data = await adminFetch(`/commands/${id}`, {
+        method: 'PATCH',
+        body: JSON.stringify({ display_limit: Number(value) }),
+    });
+    if (data) {
+        showAdminMessage(data.message, data.success ? 'success' : 'error');
+        loadCommands();
+    }
+}
+
+// 恢复隔离口令
+async function restoreCommand(id) {
+    const data = await adminFetch(`/quarantine/${id}/restore`, { method: 'POST' });
+    if (data) {
+        showAdminMessage(data.message, data.success ? 'success' : 'error');
+        loadQuarantine();
+        loadCommands();
+    }
+}
+
+// 彻底删除隔离口令
+async function purgeCommand(id) {
+    if (!confirm('确定彻底删除该口令？')) {
+        return;
+    }
+    const data = await adminFetch(`/quarantine/${id}`, { method: 'DELETE' });
+    if (data) {
+        showAdminMessage(data.message, data.success ? 'success' : 'error');
+        loadQuarantine();
+    }
+}
+
+// 手动添加
+document.getElementById('addBtn').addEventListener('click', async () => {
+    const content = document.getElementById('addContent').value.trim();
+    const limit = Number(document.getElementById('addLimit').value) || 0;
+
+    const data = await adminFetch('/commands', {
+        method: 'POST',
+        body: JSON.stringify({ content, display_limit: limit }),
+    });
+    if (data) {
+        showAdminMessage(data.message, data.success ? 'success' : 'error');
+        if (data.success) {
+            document.getElementById('addContent').value = '';
+            loadCommands();
+        }
+    }
+});
+
+// 加载定时任务
+async function loadJobs() {
+    const data = await adminFetch('/jobs');
+    if (!data || !data.success) {
+        return;
+    }
+
+    document.getElementById('jobRows').innerHTML = data.items.map((job) => {
+        let state = job.enabled ? '已启用' : '已禁用';
+        if (job.running) {
+            state += '（执行中）';
+        }
+        const lastRun = job.last_run
+            ? `${new Date(job.last_run).toLocaleString()}${job.last_error ? `<br><span class="admin-error">${escapeHtml(job.last_error)}</span>` : ''}`
+            : '-';
+        return `
+            <tr>
+                <td>${escapeHtml(job.name)}</td>
+                <td>${escapeHtml(job.spec)}</td>
+                <td>${state}</td>
+                <td>${job.next_run ? new Date(job.next_run).toLocaleString() : '-'}</td>
+                <td>${lastRun}</td>
+                <td>
+                    <button class="admin-btn" data-job="${escapeHtml(job.name)}" data-action="run">立即执行</button>
+                    <button class="admin-btn" data-job="${escapeHtml(job.name)}" data-action="${job.enabled ? 'disable' : 'enable'}">${job.enabled ? '禁用' : '启用'}</button>
+                </td>
+            </tr>
+        `;
+    }).join('');
+
+    document.querySelectorAll('#jobRows [data-job]').forEach((btn) => {
+        btn.addEventListener('click', () => jobAction(btn.dataset.job, btn.dataset.action));
+    });
+}
+
+// 执行/启用/禁用定时任务
+async function jobAction(name, action) {
+    const data = await adminFetch(`/jobs/${encodeURIComponent(name)}/${action}`, { method: 'POST' });
+    if (data) {
+        showAdminMessage(data.message, data.success ? 'success' : 'error');
+        loadJobs();
+    }
+}
+
+// 显示管理页提示
+function showAdminMessage(message, type) {
+    showMessage(document.getElementById('adminMessage'), message, type);
+}
+
+// 显示消息
+function showMessage(element, message, type) {
+    element.textContent = message;
+    element.className = `message ${type}`;
+    element.style.display = 'block';
+}
+
+// HTML转义（同时转义引号，结果可以放在属性值中）
+function escapeHtml(text) {
+    return String(text ?? '')
+        .replace(/&/g, '&amp;')
+        .replace(/</g, '&lt;')
+        .replace(/>/g, '&gt;')
+        .replace(/"/g, '&quot;')
+        .replace(/'/g, '&#39;');
+}
@@ -69,6 +69,9 @@ document.getElementById('getBtn').addEventListener('click', async () => {
     resultEl.classList.remove('show');
     messageEl.style.display = 'none';
 
+    // 重新获取前归还上一个未确认的口令
+    releaseCurrentClaim();
+
     try {
         const response = await fetch(`${API_BASE}/random`);
 
@@ -82,6 +85,8 @@ document.getElementById('getBtn').addEventListener('click', async () => {
         const data = await response.json();
 
         if (data.success) {
+            currentClaimToken = data.token;
+            claimConfirmed = false;
             showCommand(resultEl, data.content);
         } else {
             showMessage(messageEl, data.message || '暂无可用口令，请先上传你的口令', 'error');
@@ -97,6 +102,43 @@ document.getElementById('getBtn').addEventListener('click', async () => {
 
 // 全局变量存储当前口令内容
 let currentCommandContent = '';
+// 当前口令的领取令牌，复制后确认领取，离开页面或重新获取时归还
+let currentClaimToken = '';
+let claimConfirmed = false;
+
+// 离开页面时归还未确认的口令，避免白白占用名额
+window.addEventListener('pagehide', () => {
+    if (currentClaimToken && !claimConfirmed) {
+        navigator.sendBeacon(`${API_BASE}/claims/${currentClaimToken}/release`);
+        currentClaimToken = '';
+    }
+});
+
+// 确认领取当前口令（计入展示次数）
+async function confirmCurrentClaim() {
+    if (!currentClaimToken || claimConfirmed) {
+        return;
+    }
+    claimConfirmed = true;
+    try {
+        await fetch(`${API_BASE}/claims/${currentClaimToken}/confirm`, { method: 'POST' });
+        loadStats();
+    } catch (error) {
+        console.error('确认领取失败:', error);
+    }
+}
+
+// 归还当前未确认的口令
+function releaseCurrentClaim() {
+    if (!currentClaimToken || claimConfirmed) {
+        return;
+    }
+    const token = currentClaimToken;
+    currentClaimToken = '';
+    fetch(`${API_BASE}/claims/${token}/release`, { method: 'POST' }).catch((error) => {
+        console.error('归还口令失败:', error);
+    });
+}
 
 // 显示口令
 function showCommand(element, content) {
@@ -120,6 +162,13 @@ function showCommand(element, content) {
                 报告无效
             </button>
         </div>
+        <div class="feedback-actions">
+            <span class="feedback-label">领取结果：</span>
+            <button class="feedback-btn" onclick="sendFeedback('success')">领到了</button>
+            <button class="feedback-btn" onclick="sendFeedback('already_claimed')">已被领完</button>
+            <button class="feedback-btn" onclick="sendFeedback('expired')">已过期</button>
+            <button class="feedback-btn" onclick="sendFeedback('malformed')">口令无法识别</button>
+        </div>
         <div id="copyNotice" class="copy-notice"></div>
     `;
     element.classList.add('show');
@@ -129,6 +178,9 @@ function showCommand(element, content) {
 function copyCommand() {
     const noticeEl = document.getElementById('copyNotice');
 
+    // 复制即视为领取
+    confirmCurrentClaim();
+
     navigator.clipboard.writeText(currentCommandContent).then(() => {
         showCopyNotice(noticeEl, '✅ 口令已复制！现在打开腾讯元宝APP即可领取红包', 'success');
     }).catch(() => {
@@ -159,7 +211,7 @@ async function reportInvalid() {
         const data = await response.json();
 
         if (data.success) {
-            showCopyNotice(noticeEl, '✅ 已删除该口令，感谢反馈！', 'success');
+            showCopyNotice(noticeEl, `✅ ${data.message}`, 'success');
             // 3秒后清空显示
             setTimeout(() => {
                 document.getElementById('commandResult').classList.remove('show');
@@ -173,6 +225,39 @@ async function reportInvalid() {
     }
 }
 
+// 反馈领取结果
+async function sendFeedback(outcome) {
+    const noticeEl = document.getElementById('copyNotice');
+
+    if (!currentClaimToken) {
+        showCopyNotice(noticeEl, '请先获取口令', 'error');
+        return;
+    }
+
+    try {
+        const response = await fetch(`${API_BASE}/claims/${currentClaimToken}/feedback`, {
+            method: 'POST',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            body: JSON.stringify({ outcome })
+        });
+
+        const data = await response.json();
+
+        if (data.success) {
+            // 反馈后领取已结束，无需再确认或归还
+            claimConfirmed = true;
+            showCopyNotice(noticeEl, '✅ 感谢反馈！', 'success');
+            loadStats();
+        } else {
+            showCopyNotice(noticeEl, data.message || '反馈失败', 'error');
+        }
+    } catch (error) {
+        showCopyNotice(noticeEl, '❌ 网络错误，请稍后重试', 'error');
+    }
+}
+
 // 显示复制提示
 function showCopyNotice(element, message, type) {
     element.textContent = message;
@@ -192,9 +277,12 @@ function showMessage(element, message, type) {
     element.style.display = 'block';
 }
 
-// HTML转义
+// HTML转义（同时转义引号，结果可以放在属性值中）
 function escapeHtml(text) {
-    const div = document.createElement('div');
-    div.textContent = text;
-    return div.innerHTML;
+    return String(text ?? '')
+        .replace(/&/g, '&amp;')
+        .replace(/</g, '&lt;')
+        .replace(/>/g, '&gt;')
+        .replace(/"/g, '&quot;')
+        .replace(/'/g, '&#39;');
 }